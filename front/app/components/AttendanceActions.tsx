@@ -13,7 +13,7 @@ interface SlackUser {
   team_name: string;
   user_name: string;
   scope: string;
-  access_token: string;
+  session_token: string;
 }
 
 interface AttendanceActionsProps {
@@ -24,6 +24,15 @@ interface AttendanceActionsProps {
   className?: string;
 }
 
+type AttendanceAction = 'check-in' | 'check-out' | 'break-start' | 'break-end';
+
+const actionLabels: Record<AttendanceAction, string> = {
+  'check-in': '出勤',
+  'check-out': '退勤',
+  'break-start': '休憩開始',
+  'break-end': '休憩終了',
+};
+
 export default function AttendanceActions({
   user,
   selectedChannel,
@@ -33,8 +42,10 @@ export default function AttendanceActions({
 }: AttendanceActionsProps) {
   const [loading, setLoading] = useState<string | null>(null);
   const [message, setMessage] = useState<string | null>(null);
+  // チャンネルに職場が複数あって対象を決められなかったときに選んでもらう候補
+  const [picker, setPicker] = useState<{ action: AttendanceAction; candidates: string[] } | null>(null);
 
-  const handleAction = async (action: 'check-in' | 'check-out') => {
+  const handleAction = async (action: AttendanceAction, workplace?: string) => {
     if (!selectedChannel) {
       setMessage("チャンネルを選択してください");
       setTimeout(() => setMessage(null), 3000);
@@ -43,28 +54,35 @@ export default function AttendanceActions({
 
     setLoading(action);
     setMessage(null);
+    setPicker(null);
 
     try {
       const apiBaseUrl = apiUrl;
-      const endpoint = action === 'check-in' ? '/api/v1/attendance/check-in' : '/api/v1/attendance/check-out';
+      const endpoint = `/api/v1/attendance/${action}`;
       
       const response = await fetch(`${apiBaseUrl}${endpoint}`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
+          'Authorization': `Bearer ${user.session_token}`,
         },
         body: JSON.stringify({
-          team_id: user.team_id,
           channel_id: selectedChannel.id,
-          user_id: user.user_id,
+          ...(workplace ? { workplace } : {}),
         }),
       });
 
-      if (!response.ok) {
-        throw new Error(`HTTP error! status: ${response.status}`);
+      const data = await response.json().catch(() => null);
+
+      if (data?.error === 'ambiguous_workplace' && Array.isArray(data.candidates)) {
+        setPicker({ action, candidates: data.candidates });
+        return;
       }
 
-      const data = await response.json();
+      if (!response.ok) {
+        // エラー応答の message にはサーバーが判定した理由（already checked in など）が入っている
+        throw new Error(data?.message || `HTTP error! status: ${response.status}`);
+      }
 
       if (data.success) {
         setMessage(`✅ ${data.message}`);
@@ -73,7 +91,7 @@ export default function AttendanceActions({
         setMessage(`❌ ${data.message}`);
       }
     } catch (error) {
-      setMessage(`❌ ${action === 'check-in' ? '出勤' : '退勤'}記録に失敗しました: ${error instanceof Error ? error.message : 'Unknown error'}`);
+      setMessage(`❌ ${actionLabels[action]}の記録に失敗しました: ${error instanceof Error ? error.message : 'Unknown error'}`);
     } finally {
       setLoading(null);
       setTimeout(() => setMessage(null), 5000);
@@ -94,6 +112,23 @@ export default function AttendanceActions({
         </div>
       )}
 
+      {picker && (
+        <div className="mb-4 p-3 bg-blue-50 text-blue-700 border border-blue-200 rounded-lg text-sm">
+          <p className="mb-2">{actionLabels[picker.action]}する職場を選んでください</p>
+          <div className="flex flex-wrap gap-2">
+            {picker.candidates.map((name) => (
+              <button
+                key={name}
+                onClick={() => handleAction(picker.action, name)}
+                className="px-3 py-1 bg-white border border-blue-300 rounded-lg hover:bg-blue-100"
+              >
+                {name}
+              </button>
+            ))}
+          </div>
+        </div>
+      )}
+
       {!selectedChannel && (
         <div className="mb-4 p-3 bg-yellow-50 text-yellow-700 border border-yellow-200 rounded-lg text-sm">
           ⚠️ チャンネルを選択してから勤怠記録を行ってください
@@ -143,6 +178,26 @@ export default function AttendanceActions({
         </button>
       </div>
 
+      <div className="flex gap-3 mt-3">
+        {(['break-start', 'break-end'] as const).map((action) => (
+          <button
+            key={action}
+            onClick={() => handleAction(action)}
+            disabled={!selectedChannel || loading === action}
+            className="flex-1 inline-flex items-center justify-center px-4 py-2 bg-amber-500 text-white font-semibold rounded-lg hover:bg-amber-600 focus:ring-4 focus:ring-amber-300 transition-all duration-200 disabled:opacity-50 disabled:cursor-not-allowed"
+          >
+            {loading === action ? (
+              <>
+                <div className="animate-spin rounded-full h-4 w-4 border-b-2 border-white mr-2"></div>
+                処理中...
+              </>
+            ) : (
+              actionLabels[action]
+            )}
+          </button>
+        ))}
+      </div>
+
       {selectedChannel && (
         <p className="mt-3 text-sm text-gray-500">
           記録先: #{selectedChannel.name}
@@ -8,7 +8,7 @@ interface Channel {
 }
 
 interface ChannelSelectorProps {
-  accessToken: string;
+  sessionToken: string;
   selectedChannel?: Channel | null;
   onChannelSelect: (channel: Channel) => void;
   apiUrl: string;
@@ -16,7 +16,7 @@ interface ChannelSelectorProps {
 }
 
 export default function ChannelSelector({
-  accessToken,
+  sessionToken,
   selectedChannel,
   onChannelSelect,
   apiUrl,
@@ -29,11 +29,11 @@ export default function ChannelSelector({
 
   useEffect(() => {
     fetchChannels();
-  }, [accessToken]);
+  }, [sessionToken]);
 
   const fetchChannels = async () => {
-    if (!accessToken) {
-      console.log('No access token, returning early');
+    if (!sessionToken) {
+      console.log('No session token, returning early');
       return;
     }
 
@@ -43,10 +43,15 @@ export default function ChannelSelector({
 
     try {
       const apiBaseUrl = apiUrl;
-      const requestUrl = `${apiBaseUrl}/api/v1/slack/channels?access_token=${encodeURIComponent(accessToken)}`;
+      // Slackのトークンはサーバー側で保管しているため、セッショントークンだけを送る
+      const requestUrl = `${apiBaseUrl}/api/v1/slack/channels`;
       console.log('Fetching channels from:', requestUrl);
       
-      const response = await fetch(requestUrl);
+      const response = await fetch(requestUrl, {
+        headers: {
+          'Authorization': `Bearer ${sessionToken}`,
+        },
+      });
       console.log('Response status:', response.status);
       console.log('Response headers:', response.headers);
 
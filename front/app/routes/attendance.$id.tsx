@@ -5,13 +5,15 @@ import { Link, useParams, useLoaderData } from "react-router";
 type AttendanceRecord = {
   UserID: string;
   Timestamp: string;
-  WorkplaceID: string;
+  workplace_id: string;
+  workplace_name: string;
   Action: "check_in" | "check_out";
 };
 
 type UserAttendanceDetail = {
   UserID: string;
-  WorkplaceID: string;
+  workplace_id: string;
+  workplace_name: string;
   Records: AttendanceRecord[];
   TotalWorkingHours: number;
   CheckInTime?: string;
@@ -185,8 +187,8 @@ export default function AttendanceDetail() {
             <h2 className="text-lg font-semibold text-gray-900 mb-4">勤務地情報</h2>
             <div className="space-y-4">
               <div className="flex justify-between items-center">
-                <span className="text-gray-600">勤務地ID:</span>
-                <span className="font-medium text-gray-900">{userDetail.WorkplaceID}</span>
+                <span className="text-gray-600">勤務地:</span>
+                <span className="font-medium text-gray-900">{userDetail.workplace_name}</span>
               </div>
               <div className="flex justify-between items-center">
                 <span className="text-gray-600">記録数:</span>
@@ -242,7 +244,7 @@ export default function AttendanceDetail() {
                             {getActionText(record.Action)}
                           </span>
                           <p className="text-xs text-gray-500 mt-1">
-                            {record.WorkplaceID}
+                            {record.workplace_name}
                           </p>
                         </div>
                       </div>
@@ -9,7 +9,8 @@ type AttendanceRecord = {
   UserID: string;
   Timestamp: string;
   WorkplaceID: string;
-  Action: "check_in" | "check_out";
+  WorkplaceName: string;
+  Action: "check_in" | "check_out" | "break_start" | "break_end";
 };
 
 type DailyWorkSummary = {
@@ -26,7 +27,7 @@ type SlackUser = {
   team_name: string;
   user_name: string;
   scope: string;
-  access_token: string;
+  session_token: string;
 };
 
 type Channel = {
@@ -64,6 +65,8 @@ export default function Attendance() {
     new Date().toISOString().slice(0, 7) // YYYY-MM format
   );
   const [selectedChannel, setSelectedChannel] = useState<Channel | null>(null);
+  // 職場のタイムゾーン（月次APIのレスポンスで上書きする）
+  const [timeZone, setTimeZone] = useState("Asia/Tokyo");
   const [selectedDateDetail, setSelectedDateDetail] = useState<Date | null>(null);
   const [showDetailModal, setShowDetailModal] = useState(false);
 
@@ -120,20 +123,25 @@ export default function Attendance() {
       
       // Use authenticated user's data with selected channel
       const params = new URLSearchParams({
-        team_id: user.team_id,
         channel_id: selectedChannel.id,
-        user_id: user.user_id,
         year_month: yearMonth
       });
       
       const apiBaseUrl = apiUrl;
-      const response = await fetch(`${apiBaseUrl}/api/v1/attendance/monthly?${params}`);
+      const response = await fetch(`${apiBaseUrl}/api/v1/attendance/monthly?${params}`, {
+        headers: {
+          'Authorization': `Bearer ${user.session_token}`,
+        },
+      });
       
       if (!response.ok) {
         throw new Error(`HTTP error! status: ${response.status}`);
       }
       
       const data = await response.json() as any;
+      if (data.timezone) {
+        setTimeZone(data.timezone);
+      }
       if (data.success && data.attendance_logs) {
         // Convert backend format to frontend format
         const convertedRecords: AttendanceRecord[] = data.attendance_logs.map((log: any, index: number) => {
@@ -141,8 +149,11 @@ export default function Attendance() {
             ID: log.ID || `${log.UserID}-${log.Timestamp}-${index}`, // Generate ID if not provided
             UserID: log.UserID,
             Timestamp: log.Timestamp,
-            WorkplaceID: log.WorkplaceID,
-            Action: log.Action === "start" ? "check_in" : "check_out"
+            WorkplaceID: log.workplace_id,
+            WorkplaceName: log.workplace_name,
+            Action: log.Action === "start" ? "check_in"
+              : log.Action === "break_start" || log.Action === "break_end" ? log.Action
+              : "check_out"
           };
         });
         setRecords(convertedRecords);
@@ -212,7 +223,7 @@ export default function Attendance() {
     return date.toLocaleTimeString("ja-JP", {
       hour: "2-digit",
       minute: "2-digit",
-      timeZone: "Asia/Tokyo"
+      timeZone
     });
   };
 
@@ -224,15 +235,23 @@ export default function Attendance() {
     }
     
     return date.toLocaleDateString("ja-JP", {
-      timeZone: "Asia/Tokyo"
+      timeZone
     });
   };
 
   const getActionText = (action: string) => {
-    return action === "check_in" ? "出勤" : "退勤";
+    switch (action) {
+      case "check_in": return "出勤";
+      case "break_start": return "休憩開始";
+      case "break_end": return "休憩終了";
+      default: return "退勤";
+    }
   };
 
   const getActionColor = (action: string) => {
+    if (action === "break_start" || action === "break_end") {
+      return "bg-amber-100 text-amber-800";
+    }
     return action === "check_in" 
       ? "bg-green-100 text-green-800" 
       : "bg-red-100 text-red-800";
@@ -265,6 +284,19 @@ export default function Attendance() {
         if (inTime && outTime) {
           workingHours = (outTime.getTime() - inTime.getTime()) / (1000 * 60 * 60);
         }
+        // 休憩開始〜休憩終了の時間を差し引く
+        let breakStart: Date | null = null;
+        for (const r of sortedRecords) {
+          if (r.Action === 'break_start') {
+            breakStart = parseGoTimestamp(r.Timestamp);
+          } else if (r.Action === 'break_end' && breakStart) {
+            const breakEnd = parseGoTimestamp(r.Timestamp);
+            if (breakEnd) {
+              workingHours -= (breakEnd.getTime() - breakStart.getTime()) / (1000 * 60 * 60);
+            }
+            breakStart = null;
+          }
+        }
       }
       
       return {
@@ -424,7 +456,7 @@ export default function Attendance() {
             {/* チャンネル選択 */}
             <div className="mb-4">
               <ChannelSelector
-                accessToken={user.access_token}
+                sessionToken={user.session_token}
                 selectedChannel={selectedChannel}
                 onChannelSelect={handleChannelSelect}
                 apiUrl={apiUrl}
@@ -736,7 +768,7 @@ export default function Attendance() {
                                     記録ID: {record.ID}
                                   </div>
                                   <div className="text-xs text-gray-500">
-                                    勤務地: {record.WorkplaceID}
+                                    勤務地: {record.WorkplaceName}
                                   </div>
                                 </div>
                               </div>
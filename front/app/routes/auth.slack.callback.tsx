@@ -54,7 +54,9 @@ export default function AuthCallback() {
           return;
         }
 
-        const response = await fetch(`${apiUrl}/auth/slack/callback?code=${code}&state=${state}`);
+        // ログイン開始時に発行された state の Cookie をサーバーで照合するため credentials を含める
+        const params = new URLSearchParams({ code, state: state ?? '' });
+        const response = await fetch(`${apiUrl}/auth/slack/callback?${params}`, { credentials: 'include' });
         const data = await response.json();
 
         if (!response.ok || !data.success) {
@@ -36,7 +36,8 @@ export default function Login() {
       setIsLoading(true);
       setError(null);
 
-      const response = await fetch(`${apiUrl}/auth/slack`);
+      // state を保持する Cookie を受け取るため credentials を含める
+      const response = await fetch(`${apiUrl}/auth/slack`, { credentials: "include" });
       if (!response.ok) {
         throw new Error("ログイン開始に失敗しました");
       }
@@ -0,0 +1,40 @@
+// migrate-timestamp は AttendanceLog の timestamp 属性を
+// time.Time.String() の旧形式から RFC3339 (UTC・ミリ秒) に一括で書き換えるワンショットのコマンド。
+//
+//	ENV=dev go run ./cmd/migrate-timestamp -dry-run
+//	ENV=dev go run ./cmd/migrate-timestamp
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/yuorei/attendance/src/adapter/infrastructure"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "書き込みを行わずに件数だけを確認する")
+	flag.Parse()
+
+	ctx := context.Background()
+	infra := infrastructure.NewInfrastructure()
+
+	log.Printf("AttendanceLog の timestamp 移行を開始します (ENV=%s, dry-run=%t)", os.Getenv("ENV"), *dryRun)
+	result, err := infra.MigrateAttendanceLogTimestamps(ctx, *dryRun, func(r infrastructure.TimestampMigrationResult) {
+		log.Printf("進捗: scanned=%d migrated=%d skipped=%d failed=%d", r.Scanned, r.Migrated, r.Skipped, len(r.Failures))
+	})
+	if err != nil {
+		log.Printf("移行を中断しました: %v", err)
+	}
+
+	for _, f := range result.Failures {
+		log.Printf("失敗: id=%s timestamp=%q: %v", f.ID, f.Timestamp, f.Err)
+	}
+	log.Printf("完了: scanned=%d migrated=%d skipped=%d failed=%d", result.Scanned, result.Migrated, result.Skipped, len(result.Failures))
+
+	if err != nil || len(result.Failures) > 0 {
+		os.Exit(1)
+	}
+}
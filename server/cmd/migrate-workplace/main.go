@@ -0,0 +1,40 @@
+// migrate-workplace は職場名と設定を WorkplaceBindings に直接持っていた旧形式の登録から
+// Workplaces テーブルの職場を作り、登録に workplace_id を設定するワンショットのコマンド。
+//
+//	ENV=dev go run ./cmd/migrate-workplace -dry-run
+//	ENV=dev go run ./cmd/migrate-workplace
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/yuorei/attendance/src/adapter/infrastructure"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "書き込みを行わずに件数だけを確認する")
+	flag.Parse()
+
+	ctx := context.Background()
+	infra := infrastructure.NewInfrastructure()
+
+	log.Printf("WorkplaceBindings の職場の移行を開始します (ENV=%s, dry-run=%t)", os.Getenv("ENV"), *dryRun)
+	result, err := infra.MigrateWorkplaceBindings(ctx, *dryRun, func(r infrastructure.WorkplaceMigrationResult) {
+		log.Printf("進捗: scanned=%d migrated=%d skipped=%d failed=%d", r.Scanned, r.Migrated, r.Skipped, len(r.Failures))
+	})
+	if err != nil {
+		log.Printf("移行を中断しました: %v", err)
+	}
+
+	for _, f := range result.Failures {
+		log.Printf("失敗: id=%s: %v", f.ID, f.Err)
+	}
+	log.Printf("完了: scanned=%d migrated=%d skipped=%d failed=%d", result.Scanned, result.Migrated, result.Skipped, len(result.Failures))
+
+	if err != nil || len(result.Failures) > 0 {
+		os.Exit(1)
+	}
+}
@@ -2,8 +2,11 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -14,13 +17,23 @@ import (
 )
 
 var (
+	tableWorkplaces         = "Workplaces-" + os.Getenv("ENV")
 	tableWorkplaceBindings  = "WorkplaceBindings-" + os.Getenv("ENV")
 	tableAttendanceLog      = "AttendanceLog-" + os.Getenv("ENV")
+	tableAttendanceAudit    = "AttendanceAudit-" + os.Getenv("ENV")
 	indexCompositeKey       = "CompositeKey-index"
+	indexWorkplaceID        = "WorkplaceID-index"
+	indexTeamID             = "TeamID-index"
 	indexWorkplaceTimestamp = "gsi_workplace_timestamp"
 )
 
-func (i *Infrastructure) getWorkplaceBinding(ctx context.Context, teamID, channelID, userID string) (*domain.WorkplaceBindings, error) {
+// activeWorkplaceCondition は職場の登録が存在し、解除されていないことを表す条件式。
+// deleted_at は登録時に NULL 型で保存されるため、属性が無い場合と NULL 型の場合を未解除とみなす。
+// 値のプレースホルダー :nullType に "NULL" を渡すこと。
+const activeWorkplaceCondition = "attribute_exists(id) AND (attribute_not_exists(deleted_at) OR attribute_type(deleted_at, :nullType))"
+
+// queryWorkplaceBindings は CompositeKey-index を team#channel#user で検索し、登録を解除した職場も含めてすべて返す。
+func (i *Infrastructure) queryWorkplaceBindings(ctx context.Context, teamID, channelID, userID string) ([]domain.WorkplaceBindings, error) {
 	compositeKey := fmt.Sprintf("%s#%s#%s", teamID, channelID, userID)
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String(tableWorkplaceBindings),
@@ -29,129 +42,418 @@ func (i *Infrastructure) getWorkplaceBinding(ctx context.Context, teamID, channe
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":compositeKey": &types.AttributeValueMemberS{Value: compositeKey},
 		},
-		Limit: aws.Int32(1),
 	}
-	output, err := i.db.Database.Query(ctx, input)
+	items, err := i.queryAll(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get WorkplaceBinding: %w", err)
 	}
-	if len(output.Items) == 0 {
-		return nil, fmt.Errorf("WorkplaceBinding not found")
-	}
-	var binding domain.WorkplaceBindings
-	if err := attributevalue.UnmarshalMap(output.Items[0], &binding); err != nil {
+	bindings := make([]domain.WorkplaceBindings, 0, len(items))
+	if err := attributevalue.UnmarshalListOfMaps(items, &bindings); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal WorkplaceBinding: %w", err)
 	}
 
-	return &binding, nil
+	return bindings, nil
 }
 
-func (i *Infrastructure) getLatestAttendanceLog(ctx context.Context, workplaceID string) (*domain.AttendanceLog, error) {
-	input := &dynamodb.QueryInput{
-		TableName:              aws.String(tableAttendanceLog),
-		IndexName:              aws.String(indexWorkplaceTimestamp),
-		KeyConditionExpression: aws.String("workplace_id = :wpid"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":wpid": &types.AttributeValueMemberS{Value: workplaceID},
-		},
-		ScanIndexForward: aws.Bool(false),
-		Limit:            aws.Int32(1),
-	}
-	output, err := i.db.Database.Query(ctx, input)
+// getWorkplaceBindings はチャンネルに登録中の職場を登録順にすべて返す。登録を解除した職場は含めない。
+// 登録が無い場合は空のスライスを返す。
+func (i *Infrastructure) getWorkplaceBindings(ctx context.Context, teamID, channelID, userID string) ([]domain.WorkplaceBindings, error) {
+	all, err := i.queryWorkplaceBindings(ctx, teamID, channelID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest AttendanceLog: %w", err)
+		return nil, err
 	}
-	var logs []domain.AttendanceLog
-	if err := attributevalue.UnmarshalListOfMaps(output.Items, &logs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal AttendanceLog: %w", err)
+	bindings := make([]domain.WorkplaceBindings, 0, len(all))
+	for _, b := range all {
+		if !b.IsArchived() {
+			bindings = append(bindings, b)
+		}
 	}
-	if len(logs) > 0 {
-		return &logs[0], nil
+	// GSI にソートキーが無いため、登録順に並べ直す
+	sort.SliceStable(bindings, func(a, b int) bool {
+		return bindings[a].CreatedAt.Before(bindings[b].CreatedAt)
+	})
+	if err := i.loadWorkplaces(ctx, bindings); err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return bindings, nil
+}
+
+func (i *Infrastructure) DBGetWorkplaceBindings(ctx context.Context, teamID, channelID, userID string) ([]domain.WorkplaceBindings, error) {
+	return i.getWorkplaceBindings(ctx, teamID, channelID, userID)
 }
 
-func (i *Infrastructure) putAttendanceLog(ctx context.Context, log *domain.AttendanceLog) error {
-	item, err := attributevalue.MarshalMap(log)
+// DBGetArchivedWorkplaceBindings はチャンネルで登録を解除した職場を、解除した日時の新しい順に返す。
+func (i *Infrastructure) DBGetArchivedWorkplaceBindings(ctx context.Context, teamID, channelID, userID string) ([]domain.WorkplaceBindings, error) {
+	all, err := i.queryWorkplaceBindings(ctx, teamID, channelID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal AttendanceLog: %w", err)
+		return nil, err
 	}
-	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(tableAttendanceLog),
-		Item:      item,
+	bindings := make([]domain.WorkplaceBindings, 0)
+	for _, b := range all {
+		if b.IsArchived() {
+			bindings = append(bindings, b)
+		}
+	}
+	sort.SliceStable(bindings, func(a, b int) bool {
+		return bindings[a].DeletedAt.After(*bindings[b].DeletedAt)
+	})
+	if err := i.loadWorkplaces(ctx, bindings); err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+func (i *Infrastructure) getWorkplace(ctx context.Context, id string) (*domain.Workplace, error) {
+	output, err := i.db.Database.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableWorkplaces),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to save AttendanceLog: %w", err)
+		return nil, fmt.Errorf("failed to get Workplace: %w", err)
+	}
+	if output.Item == nil {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+	var workplace domain.Workplace
+	if err := attributevalue.UnmarshalMap(output.Item, &workplace); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Workplace: %w", err)
+	}
+
+	return &workplace, nil
+}
+
+func (i *Infrastructure) DBGetWorkplace(ctx context.Context, id string) (*domain.Workplace, error) {
+	return i.getWorkplace(ctx, id)
+}
+
+// loadWorkplaces は bindings それぞれに、登録した職場の名前と設定を読み込む。
+func (i *Infrastructure) loadWorkplaces(ctx context.Context, bindings []domain.WorkplaceBindings) error {
+	for n := range bindings {
+		workplace, err := i.getWorkplace(ctx, bindings[n].WorkplaceID)
+		if err != nil {
+			return err
+		}
+		bindings[n].SetWorkplace(workplace)
+	}
+
+	return nil
+}
+
+// setWorkplaceName は勤怠記録 logs に職場名 name を設定する。
+func setWorkplaceName(logs []domain.AttendanceLog, name string) {
+	for n := range logs {
+		logs[n].WorkplaceName = name
+	}
+}
+
+// loadWorkplaceName は勤怠記録の職場名を Workplace テーブルから読み込む。
+func (i *Infrastructure) loadWorkplaceName(ctx context.Context, log *domain.AttendanceLog) error {
+	workplace, err := i.getWorkplace(ctx, log.WorkplaceID)
+	if err != nil {
+		return err
 	}
+	log.WorkplaceName = workplace.Name
 
 	return nil
 }
 
-func (i *Infrastructure) DBAddAttendanceLogStart(ctx context.Context, id, teamID, channelID, userID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
-	binding, err := i.getWorkplaceBinding(ctx, teamID, channelID, userID)
+func (i *Infrastructure) getWorkplaceBindingByID(ctx context.Context, id string) (*domain.WorkplaceBindings, error) {
+	output, err := i.db.Database.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableWorkplaceBindings),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WorkplaceBinding: %w", err)
+	}
+	if output.Item == nil {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
+	}
+	var binding domain.WorkplaceBindings
+	if err := attributevalue.UnmarshalMap(output.Item, &binding); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal WorkplaceBinding: %w", err)
+	}
+	workplace, err := i.getWorkplace(ctx, binding.WorkplaceID)
 	if err != nil {
 		return nil, err
 	}
-	latestLog, err := i.getLatestAttendanceLog(ctx, binding.ID)
+	binding.SetWorkplace(workplace)
+
+	return &binding, nil
+}
+
+// getOwnedWorkplaceBinding は ID が bindingID の職場の登録を返す。呼び出し元の team / channel / user の登録でなければ
+// 存在しない場合と同じく ErrNotSubscribed を返す。
+func (i *Infrastructure) getOwnedWorkplaceBinding(ctx context.Context, teamID, channelID, userID, bindingID string) (*domain.WorkplaceBindings, error) {
+	binding, err := i.getWorkplaceBindingByID(ctx, bindingID)
 	if err != nil {
 		return nil, err
 	}
-	if latestLog != nil && latestLog.Action == "start" {
-		return nil, fmt.Errorf("already checked in")
+	if !binding.IsOwnedBy(teamID, channelID, userID) {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
 	}
-	newLog := &domain.AttendanceLog{
-		ID:          id,
-		TeamID:      teamID,
-		UserID:      binding.UserId,
-		Timestamp:   timestamp.String(),
-		Action:      action,
-		ChannelID:   binding.CannelId,
-		WorkplaceID: binding.ID,
+
+	return binding, nil
+}
+
+// getLatestAttendanceLog は職場でのユーザーの最新の勤怠記録を返す。記録が無い場合は nil を返す。
+// チームの職場は他のメンバーの記録も含むため、新しい順に読んでユーザーの記録が見つかるまで Query を繰り返す。
+func (i *Infrastructure) getLatestAttendanceLog(ctx context.Context, workplaceID, userID string) (*domain.AttendanceLog, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableAttendanceLog),
+		IndexName:              aws.String(indexWorkplaceTimestamp),
+		KeyConditionExpression: aws.String("workplace_id = :wpid"),
+		FilterExpression:       aws.String("user_id = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":wpid":   &types.AttributeValueMemberS{Value: workplaceID},
+			":userId": &types.AttributeValueMemberS{Value: userID},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+	for {
+		output, err := i.db.Database.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest AttendanceLog: %w", err)
+		}
+		var logs []domain.AttendanceLog
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &logs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal AttendanceLog: %w", err)
+		}
+		if len(logs) > 0 {
+			return &logs[0], nil
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return nil, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
 	}
-	if err := i.putAttendanceLog(ctx, newLog); err != nil {
+}
+
+// maxTransitionAttempts は打刻が同時に行われて状態の条件が満たされなかったときに、状態を読み直して試す回数
+const maxTransitionAttempts = 3
+
+// errTransitionConflict は打刻の書き込み中に、別の打刻で職場の状態が変わったことを表す。
+var errTransitionConflict = errors.New("attendance state was changed by another request")
+
+// addAttendanceLog は直前の記録からの遷移が許されている場合のみ action を記録する。
+// 勤怠記録の追加と職場の last_action の更新を1つのトランザクションで行い、
+// last_action が読み取った値のままである場合のみ書き込むので、同時に打刻しても二重に記録されない。
+func (i *Infrastructure) addAttendanceLog(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	binding, err := i.getOwnedWorkplaceBinding(ctx, teamID, channelID, userID, bindingID)
+	if err != nil {
 		return nil, err
 	}
 
-	// TODO: WorkplaceIDに職場名を入れているのでこの実装方法を直す
-	newLog.WorkplaceID = binding.Workplace
+	return i.recordAttendanceLog(ctx, id, binding, action, timestamp, nil)
+}
 
-	return newLog, nil
+// recordAttendanceLog は binding のユーザーの勤怠記録として action を記録する。
+// audit が nil でない場合はマネージャーによる追加として、記録の created_by を設定し audit も同じトランザクションで保存する。
+func (i *Infrastructure) recordAttendanceLog(ctx context.Context, id string, binding *domain.WorkplaceBindings, action string, timestamp time.Time, audit *domain.AttendanceAudit) (*domain.AttendanceLog, error) {
+	var extra []types.TransactWriteItem
+	if audit != nil {
+		put, err := auditPut(audit)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, put)
+	}
+	if binding.IsArchived() {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "workplace %q is unsubscribed", binding.Workplace)
+	}
+
+	for attempt := 0; attempt < maxTransitionAttempts; attempt++ {
+		// GSI の読み取りは結果整合性のため、状態は本体のテーブルから強い整合性で読む
+		current, err := i.getAttendanceState(ctx, binding.ID)
+		if err != nil {
+			return nil, err
+		}
+		known := current != ""
+		if !known {
+			// last_action が無い既存の職場は、最新の勤怠記録から状態を求める
+			latestLog, err := i.getLatestAttendanceLog(ctx, binding.WorkplaceID, binding.UserId)
+			if err != nil {
+				return nil, err
+			}
+			if latestLog != nil {
+				current = latestLog.Action
+			}
+		}
+		if err := domain.ValidateActionTransition(current, action); err != nil {
+			return nil, err
+		}
+
+		newLog := &domain.AttendanceLog{
+			ID:            id,
+			TeamID:        binding.TeamId,
+			UserID:        binding.UserId,
+			Timestamp:     timestamp.UTC().Truncate(time.Millisecond),
+			Action:        action,
+			ChannelID:     binding.CannelId,
+			WorkplaceID:   binding.WorkplaceID,
+			WorkplaceName: binding.Workplace,
+		}
+		if audit != nil {
+			newLog.CreatedBy = audit.ActorID
+		}
+		err = i.transactAttendanceLog(ctx, newLog, binding.ID, current, known, extra...)
+		if errors.Is(err, errTransitionConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return newLog, nil
+	}
+
+	return nil, domain.NewError(domain.ErrConflict, "failed to save AttendanceLog: %v", errTransitionConflict)
 }
 
-func (i *Infrastructure) DBAddAttendanceLogEnd(ctx context.Context, id, teamID, channelID, userID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
-	binding, err := i.getWorkplaceBinding(ctx, teamID, channelID, userID)
+// getAttendanceState は職場の last_action を強い整合性で読み取る。
+// 打刻の途中で職場の登録が解除された場合は ErrNotSubscribed を返す。
+func (i *Infrastructure) getAttendanceState(ctx context.Context, bindingID string) (string, error) {
+	output, err := i.db.Database.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableWorkplaceBindings),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: bindingID},
+		},
+		ProjectionExpression: aws.String("last_action, deleted_at"),
+		ConsistentRead:       aws.Bool(true),
+	})
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to get WorkplaceBinding state: %w", err)
+	}
+	if output.Item == nil {
+		return "", domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
+	}
+
+	var state struct {
+		LastAction string     `dynamodbav:"last_action"`
+		DeletedAt  *time.Time `dynamodbav:"deleted_at"`
 	}
-	latestLog, err := i.getLatestAttendanceLog(ctx, binding.ID)
+	if err := attributevalue.UnmarshalMap(output.Item, &state); err != nil {
+		return "", fmt.Errorf("failed to unmarshal WorkplaceBinding state: %w", err)
+	}
+	if state.DeletedAt != nil {
+		return "", domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding is unsubscribed")
+	}
+
+	return state.LastAction, nil
+}
+
+// transactAttendanceLog は勤怠記録の追加と職場の last_action の更新をまとめて行う。
+// last_action が current のままでない（known が false の場合は last_action が既に設定されている）ときは
+// errTransitionConflict を返し、どちらも書き込まない。同じ職場への別のトランザクションと衝突した場合も同じ。
+// extra は同じトランザクションで書き込む項目。
+func (i *Infrastructure) transactAttendanceLog(ctx context.Context, log *domain.AttendanceLog, bindingID, current string, known bool, extra ...types.TransactWriteItem) error {
+	item, err := marshalMap(log)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to marshal AttendanceLog: %w", err)
 	}
-	if latestLog == nil {
-		return nil, fmt.Errorf("no start log found")
+
+	// 登録の解除と同時に打刻した場合も、解除した職場には記録しない
+	stateCondition := activeWorkplaceCondition + " AND attribute_not_exists(last_action)"
+	stateValues := map[string]types.AttributeValue{
+		":next":     &types.AttributeValueMemberS{Value: log.Action},
+		":nullType": &types.AttributeValueMemberS{Value: "NULL"},
 	}
-	if latestLog.Action == "end" {
-		return nil, fmt.Errorf("already checked out")
+	if known {
+		stateCondition = activeWorkplaceCondition + " AND last_action = :current"
+		stateValues[":current"] = &types.AttributeValueMemberS{Value: current}
 	}
 
-	newLog := &domain.AttendanceLog{
-		ID:          id,
-		TeamID:      teamID,
-		UserID:      binding.UserId,
-		Timestamp:   timestamp.String(),
-		Action:      action,
-		ChannelID:   binding.CannelId,
-		WorkplaceID: binding.ID,
+	items := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(tableAttendanceLog),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			},
+		},
+		{
+			Update: &types.Update{
+				TableName: aws.String(tableWorkplaceBindings),
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: bindingID},
+				},
+				UpdateExpression:          aws.String("SET last_action = :next"),
+				ConditionExpression:       aws.String(stateCondition),
+				ExpressionAttributeValues: stateValues,
+			},
+		},
 	}
-	if err := i.putAttendanceLog(ctx, newLog); err != nil {
-		return nil, err
+	_, err = i.db.Database.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: append(items, extra...),
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			for _, reason := range canceled.CancellationReasons {
+				switch aws.ToString(reason.Code) {
+				case "ConditionalCheckFailed", "TransactionConflict":
+					return errTransitionConflict
+				}
+			}
+		}
+		return fmt.Errorf("failed to save AttendanceLog: %w", err)
 	}
 
-	// TODO: WorkplaceIDに職場名を入れているのでこの実装方法を直す
-	newLog.WorkplaceID = binding.Workplace
+	return nil
+}
 
-	return newLog, nil
+// resetAttendanceState は勤怠記録 log の職場の登録から last_action を削除し、次の打刻で最新の勤怠記録から状態を求め直させる。
+// 勤怠記録の編集・削除で最新の記録が変わることがあるため、その後に呼ぶ。
+func (i *Infrastructure) resetAttendanceState(ctx context.Context, log *domain.AttendanceLog) error {
+	bindings, err := i.queryWorkplaceBindings(ctx, log.TeamID, log.ChannelID, log.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		if binding.WorkplaceID != log.WorkplaceID {
+			continue
+		}
+		_, err := i.db.Database.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(tableWorkplaceBindings),
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: binding.ID},
+			},
+			UpdateExpression:    aws.String("REMOVE last_action"),
+			ConditionExpression: aws.String("attribute_exists(id)"),
+		})
+		if err != nil {
+			var conditionalCheckFailed *types.ConditionalCheckFailedException
+			if errors.As(err, &conditionalCheckFailed) {
+				continue
+			}
+			return fmt.Errorf("failed to reset WorkplaceBinding state: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (i *Infrastructure) DBAddAttendanceLogStart(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return i.addAttendanceLog(ctx, id, teamID, channelID, userID, bindingID, action, timestamp)
+}
+
+func (i *Infrastructure) DBAddAttendanceLogEnd(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return i.addAttendanceLog(ctx, id, teamID, channelID, userID, bindingID, action, timestamp)
+}
+
+func (i *Infrastructure) DBAddAttendanceLogBreakStart(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return i.addAttendanceLog(ctx, id, teamID, channelID, userID, bindingID, action, timestamp)
+}
+
+func (i *Infrastructure) DBAddAttendanceLogBreakEnd(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return i.addAttendanceLog(ctx, id, teamID, channelID, userID, bindingID, action, timestamp)
 }
 
 func (i *Infrastructure) DBGetAttendanceLog(ctx context.Context, id string) (*domain.AttendanceLog, error) {
@@ -164,23 +466,32 @@ func (i *Infrastructure) DBGetAttendanceLog(ctx context.Context, id string) (*do
 	if err != nil {
 		return nil, fmt.Errorf("failed to get AttendanceLog: %w", err)
 	}
+	if output.Item == nil {
+		return nil, domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+	}
 
 	var log domain.AttendanceLog
 	if err := attributevalue.UnmarshalMap(output.Item, &log); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal AttendanceLog: %w", err)
 	}
+	if err := i.loadWorkplaceName(ctx, &log); err != nil {
+		return nil, err
+	}
 
 	return &log, nil
 }
 
-func (i *Infrastructure) DBSubscribeWorkplace(ctx context.Context, id, teamID, channelID, userID, workplace string, createdAt time.Time) (*domain.WorkplaceBindings, error) {
-	workplaceBinding, err := i.getWorkplaceBinding(ctx, teamID, channelID, userID)
-	if err != nil && err.Error() != "WorkplaceBinding not found" {
-		return nil, fmt.Errorf("failed to get WorkplaceBinding: %w", err)
+// newWorkplaceBinding は workplace を呼び出し元のチャンネルに登録する WorkplaceBindings を作る。
+// 同じチャンネルに複数の職場を登録できるが、職場名で選ぶため登録中の職場と同じ名前の職場は登録できない。
+func (i *Infrastructure) newWorkplaceBinding(ctx context.Context, id, teamID, channelID, userID string, workplace *domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error) {
+	bindings, err := i.getWorkplaceBindings(ctx, teamID, channelID, userID)
+	if err != nil {
+		return nil, err
 	}
-
-	if workplaceBinding != nil {
-		return nil, fmt.Errorf("already subscribed to workplace")
+	for _, b := range bindings {
+		if b.Workplace == workplace.Name {
+			return nil, domain.ErrAlreadySubscribed
+		}
 	}
 
 	newBinding := domain.WorkplaceBindings{
@@ -188,119 +499,286 @@ func (i *Infrastructure) DBSubscribeWorkplace(ctx context.Context, id, teamID, c
 		TeamId:       teamID,
 		CannelId:     channelID,
 		UserId:       userID,
-		Workplace:    workplace,
 		CreatedAt:    createdAt,
 		UpdatedAt:    createdAt,
 		DeletedAt:    nil,
 		CompositeKey: fmt.Sprintf("%s#%s#%s", teamID, channelID, userID),
 	}
+	newBinding.SetWorkplace(workplace)
 
-	item, err := attributevalue.MarshalMap(newBinding)
+	return &newBinding, nil
+}
+
+// DBSubscribeWorkplace は職場 workplace を作り、呼び出し元のチャンネルに登録する。職場と登録は1つのトランザクションで保存する。
+func (i *Infrastructure) DBSubscribeWorkplace(ctx context.Context, id, teamID, channelID, userID string, workplace domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error) {
+	newBinding, err := i.newWorkplaceBinding(ctx, id, teamID, channelID, userID, &workplace, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	workplaceItem, err := marshalMap(workplace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Workplace: %w", err)
+	}
+	bindingItem, err := marshalMap(newBinding)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal WorkplaceBinding: %w", err)
 	}
-	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(tableWorkplaceBindings),
-		Item:      item,
+	_, err = i.db.Database.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName:           aws.String(tableWorkplaces),
+					Item:                workplaceItem,
+					ConditionExpression: aws.String("attribute_not_exists(id)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName:           aws.String(tableWorkplaceBindings),
+					Item:                bindingItem,
+					ConditionExpression: aws.String("attribute_not_exists(id)"),
+				},
+			},
+		},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to save WorkplaceBinding: %w", err)
 	}
 
-	return &newBinding, nil
+	return newBinding, nil
 }
 
-func (i *Infrastructure) DBGetAttendanceLogListByUserAndMonth(ctx context.Context, teamID, channelID, userID, year, month string) ([]domain.AttendanceLog, error) {
-	binding, err := i.getWorkplaceBinding(ctx, teamID, channelID, userID)
+// DBUnsubscribeWorkplace は deleted_at を設定して職場の登録を論理削除する。職場と勤怠記録は削除しない。
+// 職場が無いか登録が解除されている場合は ErrNotSubscribed を返す。
+func (i *Infrastructure) DBUnsubscribeWorkplace(ctx context.Context, id string, deletedAt time.Time) (*domain.WorkplaceBindings, error) {
+	timestamp := &types.AttributeValueMemberS{Value: domain.FormatTimestamp(deletedAt)}
+	output, err := i.db.Database.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableWorkplaceBindings),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET deleted_at = :deletedAt, updated_at = :deletedAt"),
+		ConditionExpression: aws.String(activeWorkplaceCondition),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":deletedAt": timestamp,
+			":nullType":  &types.AttributeValueMemberS{Value: "NULL"},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionalCheckFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionalCheckFailed) {
+			return nil, domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
+		}
+		return nil, fmt.Errorf("failed to unsubscribe WorkplaceBinding: %w", err)
+	}
+
+	var binding domain.WorkplaceBindings
+	if err := attributevalue.UnmarshalMap(output.Attributes, &binding); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal WorkplaceBinding: %w", err)
+	}
+	workplace, err := i.getWorkplace(ctx, binding.WorkplaceID)
 	if err != nil {
 		return nil, err
 	}
+	binding.SetWorkplace(workplace)
+
+	return &binding, nil
+}
+
+// updateWorkplace は職場に update を適用し、更新後の職場を返す。職場が無い場合は ErrNotFound を返す。
+func (i *Infrastructure) updateWorkplace(ctx context.Context, id, update string, names map[string]string, values map[string]types.AttributeValue) (*domain.Workplace, error) {
+	output, err := i.db.Database.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableWorkplaces),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionalCheckFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionalCheckFailed) {
+			return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+		}
+		return nil, fmt.Errorf("failed to update Workplace: %w", err)
+	}
 
-	yearMonth := fmt.Sprintf("%s-%s", year, month) // 例: "2025-05"
+	var workplace domain.Workplace
+	if err := attributevalue.UnmarshalMap(output.Attributes, &workplace); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Workplace: %w", err)
+	}
+
+	return &workplace, nil
+}
 
+// DBRenameWorkplace は職場名を変更する。
+func (i *Infrastructure) DBRenameWorkplace(ctx context.Context, id, name string, updatedAt time.Time) (*domain.Workplace, error) {
+	return i.updateWorkplace(ctx, id, "SET #name = :name, updated_at = :updatedAt", map[string]string{
+		"#name": "name",
+	}, map[string]types.AttributeValue{
+		":name":      &types.AttributeValueMemberS{Value: name},
+		":updatedAt": &types.AttributeValueMemberS{Value: domain.FormatTimestamp(updatedAt)},
+	})
+}
+
+func (i *Infrastructure) DBUpdateWorkplaceSettings(ctx context.Context, id string, settings domain.WorkplaceSettings, updatedAt time.Time) (*domain.Workplace, error) {
+	// 設定の各属性と updated_at を SET する。設定項目が増えても UpdateExpression はここで組み立てる
+	item, err := marshalMap(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal WorkplaceSettings: %w", err)
+	}
+	item["updated_at"] = &types.AttributeValueMemberS{Value: domain.FormatTimestamp(updatedAt)}
+
+	names := make(map[string]string, len(item))
+	values := make(map[string]types.AttributeValue, len(item))
+	assignments := make([]string, 0, len(item))
+	n := 0
+	for attr, value := range item {
+		n++
+		name, placeholder := fmt.Sprintf("#a%d", n), fmt.Sprintf(":v%d", n)
+		names[name] = attr
+		values[placeholder] = value
+		assignments = append(assignments, name+" = "+placeholder)
+	}
+	sort.Strings(assignments)
+
+	return i.updateWorkplace(ctx, id, "SET "+strings.Join(assignments, ", "), names, values)
+}
+
+// DBGetAttendanceLogListByUserAndRange は [from, to) の勤怠記録を時刻順に返す。記録が無い場合は空のスライスを返す。
+func (i *Infrastructure) DBGetAttendanceLogListByUserAndRange(ctx context.Context, teamID, channelID, userID, bindingID string, from, to time.Time) ([]domain.AttendanceLog, error) {
+	binding, err := i.getOwnedWorkplaceBinding(ctx, teamID, channelID, userID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := i.queryAttendanceLogsBetween(ctx, binding.WorkplaceID, binding.UserId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	setWorkplaceName(logs, binding.Workplace)
+
+	return logs, nil
+}
+
+// DBGetAttendanceLogPageByUserAndRange は [from, to) の勤怠記録を cursor の位置から最大 limit 件、時刻順に返す。
+// ユーザーの記録への絞り込みは limit 件を読んだ後に行うため、チームの職場では続きがあっても limit 件より少ないことがある。
+func (i *Infrastructure) DBGetAttendanceLogPageByUserAndRange(ctx context.Context, teamID, channelID, userID, bindingID string, from, to time.Time, cursor string, limit int) (*domain.AttendanceLogPage, error) {
+	binding, err := i.getOwnedWorkplaceBinding(ctx, teamID, channelID, userID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, next, err := i.queryPage(ctx, attendanceLogsBetweenInput(binding.WorkplaceID, binding.UserId, from, to), cursor, int32(limit))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get AttendanceLogList from GSI %s: %w", indexWorkplaceTimestamp, err)
+	}
+
+	logs := make([]domain.AttendanceLog, 0, len(items))
+	if err := attributevalue.UnmarshalListOfMaps(items, &logs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal AttendanceLogList: %w", err)
+	}
+	setWorkplaceName(logs, binding.Workplace)
+
+	return &domain.AttendanceLogPage{Logs: logs, NextCursor: next}, nil
+}
+
+// queryAttendanceLogsBetween は職場でのユーザーの [from, to) の勤怠記録をすべて時刻順に取得する。
+func (i *Infrastructure) queryAttendanceLogsBetween(ctx context.Context, workplaceID, userID string, from, to time.Time) ([]domain.AttendanceLog, error) {
+	items, err := i.queryAll(ctx, attendanceLogsBetweenInput(workplaceID, userID, from, to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AttendanceLogList from GSI %s: %w", indexWorkplaceTimestamp, err)
+	}
+
+	logs := make([]domain.AttendanceLog, 0, len(items))
+	if err := attributevalue.UnmarshalListOfMaps(items, &logs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal AttendanceLogList: %w", err)
+	}
+
+	return logs, nil
+}
+
+// attendanceLogsBetweenInput は職場でのユーザーの [from, to) の勤怠記録を gsi_workplace_timestamp から時刻順に取得する Query を返す。
+// userID が空の場合は職場のすべてのメンバーの記録を取得する。
+func attendanceLogsBetweenInput(workplaceID, userID string, from, to time.Time) *dynamodb.QueryInput {
 	// プレースホルダー #ts を定義し、実際の属性名 "timestamp" にマッピング
 	expressionAttributeNames := map[string]string{
 		"#ts": "timestamp",
 	}
 
-	// プレースホルダー :workplaceId と :yearMonth の値を定義
+	// プレースホルダー :workplaceId, :userId, :from, :to の値を定義
+	// BETWEEN は両端を含むため、上限は期間の終わりの1ミリ秒前にする
 	expressionAttributeValues := map[string]types.AttributeValue{
-		":workplaceId": &types.AttributeValueMemberS{Value: binding.ID},
-		":yearMonth":   &types.AttributeValueMemberS{Value: yearMonth},
+		":workplaceId": &types.AttributeValueMemberS{Value: workplaceID},
+		":userId":      &types.AttributeValueMemberS{Value: userID},
+		":from":        &types.AttributeValueMemberS{Value: domain.FormatTimestamp(from)},
+		":to":          &types.AttributeValueMemberS{Value: domain.FormatTimestamp(to.Add(-time.Millisecond))},
 	}
 
 	input := &dynamodb.QueryInput{
 		TableName: aws.String(tableAttendanceLog),
 		// GSI名を指定
-		IndexName: aws.String(indexWorkplaceTimestamp),
-		// KeyConditionExpression でプレースホルダー #ts を使用
-		KeyConditionExpression: aws.String("workplace_id = :workplaceId and begins_with(#ts, :yearMonth)"),
-		// ExpressionAttributeNames を追加
-		ExpressionAttributeNames: expressionAttributeNames,
-		// ExpressionAttributeValues は変更なし
+		IndexName:                 aws.String(indexWorkplaceTimestamp),
+		KeyConditionExpression:    aws.String("workplace_id = :workplaceId and #ts BETWEEN :from AND :to"),
+		ExpressionAttributeNames:  expressionAttributeNames,
 		ExpressionAttributeValues: expressionAttributeValues,
-		// userIdでフィルタリングする場合はFilterExpressionを追加 (GSIに含まれていない属性でのフィルタ)
-		// FilterExpression: aws.String("user_id = :userId"),
-		// ExpressionAttributeValues[":userId"] = &types.AttributeValueMemberS{Value: userId},
-		// 注意: FilterExpressionはQueryの後に行われるため、大量のデータを読み込んだ後にフィルタリングすることになり、
-		//       コスト効率が悪くなる可能性があります。可能であればGSIのキー設計を見直すか、
-		//       別の方法（例：複合ソートキー user_id#timestamp など）を検討してください。
 	}
-
-	output, err := i.db.Database.Query(ctx, input)
-	if err != nil {
-		// エラー内容をより詳細に出力するとデバッグに役立ちます
-		// log.Printf("DynamoDB Query Error: %v, RequestInput: %+v", err, input)
-		return nil, fmt.Errorf("failed to get AttendanceLogListByUserAndMonth from GSI %s: %w", indexWorkplaceTimestamp, err)
+	if userID == "" {
+		delete(expressionAttributeValues, ":userId")
+	} else {
+		// チームの職場は複数のメンバーの記録を持つため、user_id で絞り込む
+		input.FilterExpression = aws.String("user_id = :userId")
 	}
 
-	var logs []domain.AttendanceLog
-	if err := attributevalue.UnmarshalListOfMaps(output.Items, &logs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal AttendanceLogListByUserAndMonth: %w", err)
-	}
+	return input
+}
 
-	// GSIにはuser_idが含まれている (projection_type = "ALL" のため) ので、
-	// 必要であればここでさらにGo側でuserIdによるフィルタリングを行うこともできますが、
-	// FilterExpressionを使う方が一般的です。
-	// もしFilterExpressionを使わない場合:
-	// filteredLogs := []AttendanceLog{}
-	// for _, log := range logs {
-	// 	if log.UserID == userId {
-	// 		filteredLogs = append(filteredLogs, log)
-	// 	}
-	// }
-	// return filteredLogs, nil
+// ownerConditionExpression は勤怠記録が呼び出し元のものである場合のみ書き込みを許可する条件式。
+// 項目が存在しない場合も条件を満たさないため、更新で新しい項目が作られることもない。
+const ownerConditionExpression = "team_id = :teamId AND channel_id = :channelId AND user_id = :userId"
 
-	if len(logs) == 0 {
-		return nil, fmt.Errorf("no attendance logs found for user %s in month %s-%s", userID, year, month)
+func ownerConditionValues(teamID, channelID, userID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		":teamId":    &types.AttributeValueMemberS{Value: teamID},
+		":channelId": &types.AttributeValueMemberS{Value: channelID},
+		":userId":    &types.AttributeValueMemberS{Value: userID},
 	}
-
-	// TODO: WorkplaceIDに職場名を入れているのでこの実装方法を直す
-	logs[0].WorkplaceID = binding.Workplace
-
-	return logs, nil
 }
 
-func (i *Infrastructure) DBUpdateAttendanceLog(ctx context.Context, id string, newTimestamp time.Time) (*domain.AttendanceLog, error) {
-	timestampStr := newTimestamp.String()
+func (i *Infrastructure) DBUpdateAttendanceLog(ctx context.Context, teamID, channelID, userID, id string, newTimestamp time.Time) (*domain.AttendanceLog, error) {
+	timestampStr := domain.FormatTimestamp(newTimestamp)
+	expressionAttributeValues := ownerConditionValues(teamID, channelID, userID)
+	expressionAttributeValues[":timestamp"] = &types.AttributeValueMemberS{Value: timestampStr}
 
 	updateInput := &dynamodb.UpdateItemInput{
 		TableName: aws.String(tableAttendanceLog),
 		Key: map[string]types.AttributeValue{
 			"id": &types.AttributeValueMemberS{Value: id},
 		},
-		UpdateExpression: aws.String("SET #ts = :timestamp"),
+		// 本人の編集でも updated_by を残し、マネージャーの編集と区別できるようにする
+		UpdateExpression:    aws.String("SET #ts = :timestamp, updated_by = :userId"),
+		ConditionExpression: aws.String(ownerConditionExpression),
 		ExpressionAttributeNames: map[string]string{
 			"#ts": "timestamp",
 		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":timestamp": &types.AttributeValueMemberS{Value: timestampStr},
-		},
-		ReturnValues: types.ReturnValueAllNew,
+		ExpressionAttributeValues: expressionAttributeValues,
+		ReturnValues:              types.ReturnValueAllNew,
 	}
 
 	output, err := i.db.Database.UpdateItem(ctx, updateInput)
 	if err != nil {
+		var conditionalCheckFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionalCheckFailed) {
+			return nil, domain.ErrForbidden
+		}
 		return nil, fmt.Errorf("failed to update AttendanceLog: %w", err)
 	}
 
@@ -309,21 +787,46 @@ func (i *Infrastructure) DBUpdateAttendanceLog(ctx context.Context, id string, n
 		return nil, fmt.Errorf("failed to unmarshal updated AttendanceLog: %w", err)
 	}
 
+	// 時刻の変更で最新の記録が入れ替わることがあるため、打刻の状態を求め直させる
+	if err := i.resetAttendanceState(ctx, &updatedLog); err != nil {
+		return nil, err
+	}
+	if err := i.loadWorkplaceName(ctx, &updatedLog); err != nil {
+		return nil, err
+	}
+
 	return &updatedLog, nil
 }
 
-func (i *Infrastructure) DBDeleteAttendanceLog(ctx context.Context, id string) error {
+func (i *Infrastructure) DBDeleteAttendanceLog(ctx context.Context, teamID, channelID, userID, id string) error {
 	deleteInput := &dynamodb.DeleteItemInput{
 		TableName: aws.String(tableAttendanceLog),
 		Key: map[string]types.AttributeValue{
 			"id": &types.AttributeValueMemberS{Value: id},
 		},
+		ConditionExpression:       aws.String(ownerConditionExpression),
+		ExpressionAttributeValues: ownerConditionValues(teamID, channelID, userID),
+		ReturnValues:              types.ReturnValueAllOld,
 	}
 
-	_, err := i.db.Database.DeleteItem(ctx, deleteInput)
+	output, err := i.db.Database.DeleteItem(ctx, deleteInput)
 	if err != nil {
+		var conditionalCheckFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionalCheckFailed) {
+			return domain.ErrForbidden
+		}
 		return fmt.Errorf("failed to delete AttendanceLog: %w", err)
 	}
 
+	var deletedLog domain.AttendanceLog
+	if err := attributevalue.UnmarshalMap(output.Attributes, &deletedLog); err != nil {
+		return fmt.Errorf("failed to unmarshal deleted AttendanceLog: %w", err)
+	}
+
+	// 最新の記録を削除した場合に備えて、打刻の状態を求め直させる
+	if err := i.resetAttendanceState(ctx, &deletedLog); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,264 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+	"github.com/yuorei/attendance/src/driver/db"
+)
+
+// 打刻の状態遷移のテストは DynamoDB Local の条件付き書き込みとトランザクションに対して実行する。
+// DYNAMODB_LOCAL_ENDPOINT (例: http://localhost:8000) が設定されていない場合はスキップする。
+
+func TestParallelCheckInRecordsOnce(t *testing.T) {
+	ctx := context.Background()
+	infra := newLocalInfrastructure(t, nil)
+	binding := subscribeTestWorkplace(t, infra)
+
+	const n = 10
+	now := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for k := 0; k < n; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			_, errs[k] = infra.DBAddAttendanceLogStart(ctx, fmt.Sprintf("log-%d", k), binding.TeamId, binding.CannelId, binding.UserId, binding.ID, domain.ActionStart, now)
+		}(k)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for k, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, domain.ErrAlreadyCheckedIn), errors.Is(err, domain.ErrConflict):
+		default:
+			t.Errorf("check-in %d error = %v, want ErrAlreadyCheckedIn or ErrConflict", k, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d of %d parallel check-ins succeeded, want exactly 1", succeeded, n)
+	}
+
+	logs := attendanceLogsOf(t, infra, binding, now)
+	if len(logs) != 1 {
+		t.Errorf("%d attendance logs were written, want 1", len(logs))
+	}
+	state, err := infra.getAttendanceState(ctx, binding.ID)
+	if err != nil {
+		t.Fatalf("getAttendanceState() error = %v", err)
+	}
+	if state != domain.ActionStart {
+		t.Errorf("last_action = %q, want %q", state, domain.ActionStart)
+	}
+}
+
+func TestRecordAttendanceLogRetriesConflict(t *testing.T) {
+	ctx := context.Background()
+	// 最初の書き込みの直前にだけ、他のリクエストが状態を変えたことにする
+	rival := &rivalWriter{remaining: 1}
+	infra := newLocalInfrastructure(t, rival)
+	binding := subscribeTestWorkplace(t, infra)
+	rival.bindingID = binding.ID
+
+	now := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := infra.DBAddAttendanceLogStart(ctx, "log-1", binding.TeamId, binding.CannelId, binding.UserId, binding.ID, domain.ActionStart, now); err != nil {
+		t.Fatalf("DBAddAttendanceLogStart() error = %v", err)
+	}
+	if got := rival.attempts.Load(); got != 2 {
+		t.Errorf("TransactWriteItems was called %d times, want 2", got)
+	}
+	if logs := attendanceLogsOf(t, infra, binding, now); len(logs) != 1 {
+		t.Errorf("%d attendance logs were written, want 1", len(logs))
+	}
+}
+
+func TestRecordAttendanceLogGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	// 毎回の書き込みの直前に他のリクエストが状態を変え、条件が満たされないようにする
+	rival := &rivalWriter{remaining: -1}
+	infra := newLocalInfrastructure(t, rival)
+	binding := subscribeTestWorkplace(t, infra)
+	rival.bindingID = binding.ID
+
+	now := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	_, err := infra.DBAddAttendanceLogStart(ctx, "log-1", binding.TeamId, binding.CannelId, binding.UserId, binding.ID, domain.ActionStart, now)
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("DBAddAttendanceLogStart() error = %v, want ErrConflict", err)
+	}
+	if got := rival.attempts.Load(); got != maxTransitionAttempts {
+		t.Errorf("TransactWriteItems was called %d times, want %d", got, maxTransitionAttempts)
+	}
+	if logs := attendanceLogsOf(t, infra, binding, now); len(logs) != 0 {
+		t.Errorf("%d attendance logs were written, want 0", len(logs))
+	}
+}
+
+// rivalWriter は TransactWriteItems の送信直前に、別のリクエストとして職場の last_action を書き換える http クライアント。
+// last_action が無ければ "end" を設定し、あれば削除する（どちらの状態からも出勤できるため、打刻は状態を読み直して再試行する）。
+// remaining 回だけ書き換え、負の場合は毎回書き換える。
+type rivalWriter struct {
+	next      *http.Client
+	db        *dynamodb.Client
+	bindingID string
+	remaining int
+	attempts  atomic.Int32
+}
+
+func (w *rivalWriter) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("X-Amz-Target") == "DynamoDB_20120810.TransactWriteItems" && w.bindingID != "" {
+		w.attempts.Add(1)
+		if w.remaining != 0 {
+			w.remaining--
+			if err := w.toggleLastAction(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return w.next.Do(req)
+}
+
+func (w *rivalWriter) toggleLastAction(ctx context.Context) error {
+	key := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: w.bindingID}}
+	output, err := w.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(tableWorkplaceBindings),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	update := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(tableWorkplaceBindings),
+		Key:              key,
+		UpdateExpression: aws.String("REMOVE last_action"),
+	}
+	if _, ok := output.Item["last_action"]; !ok {
+		update.UpdateExpression = aws.String("SET last_action = :end")
+		update.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":end": &types.AttributeValueMemberS{Value: domain.ActionEnd},
+		}
+	}
+	_, err = w.db.UpdateItem(ctx, update)
+	return err
+}
+
+// newLocalInfrastructure はテストごとに名前の異なるテーブルを DynamoDB Local に作り、それを使う Infrastructure を返す。
+// rival が nil でない場合は、TransactWriteItems の送信前に rival が状態を書き換える。
+func newLocalInfrastructure(t *testing.T, rival *rivalWriter) *Infrastructure {
+	t.Helper()
+	endpoint := os.Getenv("DYNAMODB_LOCAL_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_LOCAL_ENDPOINT is not set")
+	}
+
+	options := dynamodb.Options{
+		Region:       "us-west-2",
+		BaseEndpoint: aws.String(endpoint),
+		Credentials:  credentials.NewStaticCredentialsProvider("dummy", "dummy", ""),
+	}
+	plain := dynamodb.New(options)
+	client := plain
+	if rival != nil {
+		rival.next = &http.Client{Timeout: 10 * time.Second}
+		rival.db = plain
+		client = dynamodb.New(options, func(o *dynamodb.Options) {
+			o.HTTPClient = rival
+		})
+	}
+
+	suffix := fmt.Sprintf("-test-%d", time.Now().UnixNano())
+	tables := []*string{&tableWorkplaces, &tableWorkplaceBindings, &tableAttendanceLog, &tableAttendanceAudit}
+	originals := make([]string, len(tables))
+	for k, table := range tables {
+		originals[k] = *table
+		*table = "Attendance" + fmt.Sprint(k) + suffix
+	}
+	t.Cleanup(func() {
+		for k, table := range tables {
+			plain.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(*table)})
+			*table = originals[k]
+		}
+	})
+
+	createTestTable(t, plain, tableWorkplaces, gsi(indexTeamID, "team_id", ""))
+	createTestTable(t, plain, tableWorkplaceBindings, gsi(indexCompositeKey, "composite_key", ""), gsi(indexWorkplaceID, "workplace_id", ""))
+	createTestTable(t, plain, tableAttendanceLog, gsi(indexWorkplaceTimestamp, "workplace_id", "timestamp"))
+	createTestTable(t, plain, tableAttendanceAudit, gsi(indexWorkplaceID, "workplace_id", "created_at"))
+
+	return &Infrastructure{db: &db.DB{Database: client}}
+}
+
+// gsi はすべての属性を射影する GSI を返す。rangeKey が空の場合はパーティションキーだけを持つ。
+func gsi(name, hashKey, rangeKey string) types.GlobalSecondaryIndex {
+	index := types.GlobalSecondaryIndex{
+		IndexName:  aws.String(name),
+		KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String(hashKey), KeyType: types.KeyTypeHash}},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+	if rangeKey != "" {
+		index.KeySchema = append(index.KeySchema, types.KeySchemaElement{AttributeName: aws.String(rangeKey), KeyType: types.KeyTypeRange})
+	}
+	return index
+}
+
+// createTestTable は id をパーティションキーとし、indexes を持つテーブルを作る。
+func createTestTable(t *testing.T, client *dynamodb.Client, name string, indexes ...types.GlobalSecondaryIndex) {
+	t.Helper()
+	attributes := map[string]bool{"id": true}
+	for _, index := range indexes {
+		for _, key := range index.KeySchema {
+			attributes[aws.ToString(key.AttributeName)] = true
+		}
+	}
+	definitions := make([]types.AttributeDefinition, 0, len(attributes))
+	for attr := range attributes {
+		definitions = append(definitions, types.AttributeDefinition{AttributeName: aws.String(attr), AttributeType: types.ScalarAttributeTypeS})
+	}
+
+	_, err := client.CreateTable(context.Background(), &dynamodb.CreateTableInput{
+		TableName:              aws.String(name),
+		BillingMode:            types.BillingModePayPerRequest,
+		AttributeDefinitions:   definitions,
+		KeySchema:              []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}},
+		GlobalSecondaryIndexes: indexes,
+	})
+	if err != nil {
+		t.Fatalf("failed to create table %s: %v", name, err)
+	}
+}
+
+func subscribeTestWorkplace(t *testing.T, infra *Infrastructure) *domain.WorkplaceBindings {
+	t.Helper()
+	createdAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	workplace := domain.NewWorkplace("workplace-1", "T1", "U1", "本社", domain.WorkplaceSettings{}, createdAt)
+	binding, err := infra.DBSubscribeWorkplace(context.Background(), "binding-1", "T1", "C1", "U1", workplace, createdAt)
+	if err != nil {
+		t.Fatalf("DBSubscribeWorkplace() error = %v", err)
+	}
+	return binding
+}
+
+// attendanceLogsOf は around の前後1日に記録された binding の勤怠記録を返す。
+func attendanceLogsOf(t *testing.T, infra *Infrastructure, binding *domain.WorkplaceBindings, around time.Time) []domain.AttendanceLog {
+	t.Helper()
+	logs, err := infra.DBGetAttendanceLogListByUserAndRange(context.Background(), binding.TeamId, binding.CannelId, binding.UserId, binding.ID, around.Add(-24*time.Hour), around.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("DBGetAttendanceLogListByUserAndRange() error = %v", err)
+	}
+	return logs
+}
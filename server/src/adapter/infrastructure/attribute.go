@@ -0,0 +1,21 @@
+package infrastructure
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// encodeTime は time.Time を domain.TimestampLayout の文字列として保存する。
+// attributevalue のデフォルト(RFC3339Nano)は末尾の0を省略するため固定長にならない。
+func encodeTime(t time.Time) (types.AttributeValue, error) {
+	return &types.AttributeValueMemberS{Value: domain.FormatTimestamp(t)}, nil
+}
+
+func marshalMap(in interface{}) (map[string]types.AttributeValue, error) {
+	return attributevalue.MarshalMapWithOptions(in, func(o *attributevalue.EncoderOptions) {
+		o.EncodeTime = encodeTime
+	})
+}
@@ -0,0 +1,119 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+var tableIdempotency = "Idempotency-" + os.Getenv("ENV")
+
+// idempotencyItem は Idempotency テーブルに保存する項目。
+// expires_at は DynamoDB の TTL で期限切れの項目を削除するため、UNIX 時間（秒）で保存する。
+type idempotencyItem struct {
+	ID          string `dynamodbav:"id"`
+	RequestHash string `dynamodbav:"request_hash"`
+	Status      string `dynamodbav:"status"`
+	StatusCode  int    `dynamodbav:"status_code"`
+	ContentType string `dynamodbav:"content_type"`
+	Body        []byte `dynamodbav:"body"`
+	ExpiresAt   int64  `dynamodbav:"expires_at"`
+}
+
+func newIdempotencyItem(record *domain.IdempotencyRecord) idempotencyItem {
+	return idempotencyItem{
+		ID:          record.Key,
+		RequestHash: record.RequestHash,
+		Status:      string(record.Status),
+		StatusCode:  record.StatusCode,
+		ContentType: record.ContentType,
+		Body:        record.Body,
+		ExpiresAt:   record.ExpiresAt.Unix(),
+	}
+}
+
+func (item idempotencyItem) record() *domain.IdempotencyRecord {
+	return &domain.IdempotencyRecord{
+		Key:         item.ID,
+		RequestHash: item.RequestHash,
+		Status:      domain.IdempotencyStatus(item.Status),
+		StatusCode:  item.StatusCode,
+		ContentType: item.ContentType,
+		Body:        item.Body,
+		ExpiresAt:   time.Unix(item.ExpiresAt, 0),
+	}
+}
+
+// DBReserveIdempotencyKey はキーが無いか期限切れの場合のみ record を保存して nil を返す。
+// 既に有効な記録がある場合は保存せずにその記録を返す。TTL による削除はすぐには行われないため、期限も条件に含める。
+func (i *Infrastructure) DBReserveIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, error) {
+	item, err := marshalMap(newIdempotencyItem(record))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal IdempotencyRecord: %w", err)
+	}
+
+	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(tableIdempotency),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id) OR expires_at <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err == nil {
+		return nil, nil
+	}
+
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if !errors.As(err, &conditionalCheckFailed) {
+		return nil, fmt.Errorf("failed to save IdempotencyRecord: %w", err)
+	}
+
+	var existing idempotencyItem
+	if err := attributevalue.UnmarshalMap(conditionalCheckFailed.Item, &existing); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal IdempotencyRecord: %w", err)
+	}
+
+	return existing.record(), nil
+}
+
+func (i *Infrastructure) DBSaveIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	item, err := marshalMap(newIdempotencyItem(record))
+	if err != nil {
+		return fmt.Errorf("failed to marshal IdempotencyRecord: %w", err)
+	}
+
+	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableIdempotency),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save IdempotencyRecord: %w", err)
+	}
+
+	return nil
+}
+
+func (i *Infrastructure) DBDeleteIdempotencyRecord(ctx context.Context, key string) error {
+	_, err := i.db.Database.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableIdempotency),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete IdempotencyRecord: %w", err)
+	}
+
+	return nil
+}
@@ -1,16 +1,30 @@
 package infrastructure
 
 import (
+	"log"
+
+	"github.com/yuorei/attendance/src/driver/crypto"
 	"github.com/yuorei/attendance/src/driver/db"
 )
 
 type Infrastructure struct {
 	db *db.DB
+	// tokenCipher は Slack トークンを保存時に暗号化するためのもの。鍵が未設定の場合は nil。
+	tokenCipher    *crypto.Cipher
+	tokenCipherErr error
 }
 
 func NewInfrastructure() *Infrastructure {
+	tokenCipher, err := crypto.NewCipherFromEnv()
+	if err != nil {
+		// 鍵がなくても勤怠機能は使えるようにし、トークンの保存・取得時にエラーを返す
+		log.Printf("Slackトークンの暗号化鍵を読み込めませんでした: %v", err)
+	}
+
 	return &Infrastructure{
-		db: db.NewDBClient(),
+		db:             db.NewDBClient(),
+		tokenCipher:    tokenCipher,
+		tokenCipherErr: err,
 		// redis:     r.ConnectRedis(),
 		// yuovision: client.NewClientYuoVision(),
 		// bigquery:  googlecloud.NewBigQuery(),
@@ -0,0 +1,200 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// マネージャーによるメンバーの勤怠記録の操作。権限は usecase で確認済みのため所有者の条件は付けず、
+// 記録が操作対象の職場のものである場合のみ書き込み、操作の履歴を AttendanceAudit テーブルに同じトランザクションで残す。
+
+// auditPut は監査ログを AttendanceAudit テーブルに追加する書き込み項目を返す。
+func auditPut(audit *domain.AttendanceAudit) (types.TransactWriteItem, error) {
+	item, err := marshalMap(audit)
+	if err != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("failed to marshal AttendanceAudit: %w", err)
+	}
+
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:           aws.String(tableAttendanceAudit),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		},
+	}, nil
+}
+
+// transactMemberAttendanceLog は勤怠記録への書き込み write と監査ログの追加をまとめて行う。
+// write の条件を満たさない（記録が無いか、他の職場の記録の）場合は ErrNotFound を返し、どちらも書き込まない。
+func (i *Infrastructure) transactMemberAttendanceLog(ctx context.Context, write types.TransactWriteItem, audit *domain.AttendanceAudit) error {
+	put, err := auditPut(audit)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.db.Database.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{write, put},
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			for _, reason := range canceled.CancellationReasons {
+				if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+					return domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+				}
+			}
+		}
+		return fmt.Errorf("failed to save AttendanceAudit: %w", err)
+	}
+
+	return nil
+}
+
+// getAttendanceLogItem は勤怠記録を強い整合性で読み取る。記録が無い場合は ErrNotFound を返す。
+func (i *Infrastructure) getAttendanceLogItem(ctx context.Context, id string) (*domain.AttendanceLog, error) {
+	output, err := i.db.Database.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableAttendanceLog),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AttendanceLog: %w", err)
+	}
+	if output.Item == nil {
+		return nil, domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+	}
+
+	var log domain.AttendanceLog
+	if err := attributevalue.UnmarshalMap(output.Item, &log); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal AttendanceLog: %w", err)
+	}
+
+	return &log, nil
+}
+
+// DBGetWorkplaceAttendanceLogs は職場の [from, to) の勤怠記録を時刻順に返す。userID が空の場合はすべてのメンバーの記録を返す。
+func (i *Infrastructure) DBGetWorkplaceAttendanceLogs(ctx context.Context, workplaceID, userID string, from, to time.Time) ([]domain.AttendanceLog, error) {
+	workplace, err := i.getWorkplace(ctx, workplaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := i.queryAttendanceLogsBetween(ctx, workplaceID, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	setWorkplaceName(logs, workplace.Name)
+
+	return logs, nil
+}
+
+// DBAddMemberAttendanceLog は登録 bindingID のメンバーの勤怠記録として action を記録する。
+// 打刻と同じく直前の記録からの遷移が許されている場合のみ記録する。
+func (i *Infrastructure) DBAddMemberAttendanceLog(ctx context.Context, id, bindingID, action string, timestamp time.Time, audit domain.AttendanceAudit) (*domain.AttendanceLog, error) {
+	binding, err := i.getWorkplaceBindingByID(ctx, bindingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.recordAttendanceLog(ctx, id, binding, action, timestamp, &audit)
+}
+
+// DBUpdateMemberAttendanceLog は職場 workplaceID の勤怠記録の時刻を変更し、updated_by に操作したマネージャーを設定する。
+func (i *Infrastructure) DBUpdateMemberAttendanceLog(ctx context.Context, workplaceID, id string, newTimestamp time.Time, audit domain.AttendanceAudit) (*domain.AttendanceLog, error) {
+	err := i.transactMemberAttendanceLog(ctx, types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: aws.String(tableAttendanceLog),
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: id},
+			},
+			UpdateExpression:    aws.String("SET #ts = :timestamp, updated_by = :actorId"),
+			ConditionExpression: aws.String("workplace_id = :workplaceId"),
+			ExpressionAttributeNames: map[string]string{
+				"#ts": "timestamp",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":timestamp":   &types.AttributeValueMemberS{Value: domain.FormatTimestamp(newTimestamp)},
+				":actorId":     &types.AttributeValueMemberS{Value: audit.ActorID},
+				":workplaceId": &types.AttributeValueMemberS{Value: workplaceID},
+			},
+		},
+	}, &audit)
+	if err != nil {
+		return nil, err
+	}
+
+	// トランザクションの書き込みは更新後の項目を返さないため読み直す
+	updatedLog, err := i.getAttendanceLogItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.resetAttendanceState(ctx, updatedLog); err != nil {
+		return nil, err
+	}
+	if err := i.loadWorkplaceName(ctx, updatedLog); err != nil {
+		return nil, err
+	}
+
+	return updatedLog, nil
+}
+
+// DBDeleteMemberAttendanceLog は職場 workplaceID の勤怠記録を削除する。
+func (i *Infrastructure) DBDeleteMemberAttendanceLog(ctx context.Context, workplaceID, id string, audit domain.AttendanceAudit) error {
+	// 削除後に打刻の状態を求め直させるため、記録のメンバーと職場を先に読む
+	log, err := i.getAttendanceLogItem(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = i.transactMemberAttendanceLog(ctx, types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName: aws.String(tableAttendanceLog),
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: id},
+			},
+			ConditionExpression: aws.String("workplace_id = :workplaceId"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":workplaceId": &types.AttributeValueMemberS{Value: workplaceID},
+			},
+		},
+	}, &audit)
+	if err != nil {
+		return err
+	}
+
+	return i.resetAttendanceState(ctx, log)
+}
+
+// DBGetAttendanceAudits は AttendanceAudit テーブルの WorkplaceID-index を検索し、職場の [from, to) の監査ログを古い順に返す。
+func (i *Infrastructure) DBGetAttendanceAudits(ctx context.Context, workplaceID string, from, to time.Time) ([]domain.AttendanceAudit, error) {
+	items, err := i.queryAll(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(tableAttendanceAudit),
+		IndexName:              aws.String(indexWorkplaceID),
+		KeyConditionExpression: aws.String("workplace_id = :workplaceId AND created_at BETWEEN :from AND :to"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":workplaceId": &types.AttributeValueMemberS{Value: workplaceID},
+			":from":        &types.AttributeValueMemberS{Value: domain.FormatTimestamp(from)},
+			":to":          &types.AttributeValueMemberS{Value: domain.FormatTimestamp(to.Add(-time.Millisecond))},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AttendanceAudit: %w", err)
+	}
+
+	audits := make([]domain.AttendanceAudit, 0, len(items))
+	if err := attributevalue.UnmarshalListOfMaps(items, &audits); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal AttendanceAudit: %w", err)
+	}
+
+	return audits, nil
+}
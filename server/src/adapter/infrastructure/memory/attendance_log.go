@@ -0,0 +1,407 @@
+package memory
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// getWorkplaceBindings は CompositeKey-index への Query と同じく team#channel#user で検索し、
+// archived が true なら登録を解除した職場、false なら登録中の職場を登録順に返す。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) getWorkplaceBindings(teamID, channelID, userID string, archived bool) []domain.WorkplaceBindings {
+	compositeKey := fmt.Sprintf("%s#%s#%s", teamID, channelID, userID)
+	bindings := make([]domain.WorkplaceBindings, 0)
+	for _, binding := range m.workplaceBindings {
+		if binding.CompositeKey == compositeKey && binding.IsArchived() == archived {
+			bindings = append(bindings, m.withWorkplace(binding))
+		}
+	}
+	sort.SliceStable(bindings, func(a, b int) bool {
+		if !bindings[a].CreatedAt.Equal(bindings[b].CreatedAt) {
+			return bindings[a].CreatedAt.Before(bindings[b].CreatedAt)
+		}
+		return bindings[a].ID < bindings[b].ID
+	})
+
+	return bindings
+}
+
+func (m *Memory) DBGetWorkplaceBindings(ctx context.Context, teamID, channelID, userID string) ([]domain.WorkplaceBindings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.getWorkplaceBindings(teamID, channelID, userID, false), nil
+}
+
+// DBGetArchivedWorkplaceBindings は DynamoDB 実装と同じく、登録を解除した職場を解除した日時の新しい順に返す。
+func (m *Memory) DBGetArchivedWorkplaceBindings(ctx context.Context, teamID, channelID, userID string) ([]domain.WorkplaceBindings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bindings := m.getWorkplaceBindings(teamID, channelID, userID, true)
+	sort.SliceStable(bindings, func(a, b int) bool {
+		return bindings[a].DeletedAt.After(*bindings[b].DeletedAt)
+	})
+
+	return bindings, nil
+}
+
+// withWorkplace は DynamoDB 実装と同じく、登録した職場の名前と設定を読み込んだ binding を返す。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) withWorkplace(binding domain.WorkplaceBindings) domain.WorkplaceBindings {
+	if workplace, ok := m.workplaces[binding.WorkplaceID]; ok {
+		binding.SetWorkplace(&workplace)
+	}
+	return binding
+}
+
+func (m *Memory) DBGetWorkplace(ctx context.Context, id string) (*domain.Workplace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workplace, ok := m.workplaces[id]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+
+	return &workplace, nil
+}
+
+// workplaceName は DynamoDB 実装と同じく、勤怠記録に返す職場名を求める。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) workplaceName(workplaceID string) string {
+	return m.workplaces[workplaceID].Name
+}
+
+// getOwnedWorkplaceBinding は DynamoDB 実装と同じく、呼び出し元の登録でなければ ErrNotSubscribed を返す。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) getOwnedWorkplaceBinding(teamID, channelID, userID, bindingID string) (*domain.WorkplaceBindings, error) {
+	binding, ok := m.workplaceBindings[bindingID]
+	if !ok || !binding.IsOwnedBy(teamID, channelID, userID) {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
+	}
+	binding = m.withWorkplace(binding)
+
+	return &binding, nil
+}
+
+// queryAttendanceLogs は gsi_workplace_timestamp への Query と同じく
+// workplace_id と user_id で絞り込み、timestamp の昇順で返す。userID が空の場合は職場のすべての記録を返す。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) queryAttendanceLogs(workplaceID, userID string) []domain.AttendanceLog {
+	logs := make([]domain.AttendanceLog, 0)
+	for _, log := range m.attendanceLogs {
+		if log.WorkplaceID == workplaceID && (userID == "" || log.UserID == userID) {
+			logs = append(logs, log)
+		}
+	}
+	// 同じ時刻の記録はページ分割で順序が変わらないよう ID 順にする
+	sort.Slice(logs, func(i, j int) bool {
+		if !logs[i].Timestamp.Equal(logs[j].Timestamp) {
+			return logs[i].Timestamp.Before(logs[j].Timestamp)
+		}
+		return logs[i].ID < logs[j].ID
+	})
+
+	return logs
+}
+
+// getLatestAttendanceLog は DynamoDB 実装と同じく、職場でのユーザーの最新の勤怠記録を返す。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) getLatestAttendanceLog(workplaceID, userID string) *domain.AttendanceLog {
+	logs := m.queryAttendanceLogs(workplaceID, userID)
+	if len(logs) == 0 {
+		return nil
+	}
+
+	return &logs[len(logs)-1]
+}
+
+// addAttendanceLog は直前の記録からの遷移が許されている場合のみ action を記録する。
+func (m *Memory) addAttendanceLog(id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	binding, err := m.getOwnedWorkplaceBinding(teamID, channelID, userID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.recordAttendanceLog(id, binding, action, timestamp, nil)
+}
+
+// recordAttendanceLog は DynamoDB 実装と同じく binding のユーザーの勤怠記録として action を記録し、
+// audit が nil でない場合は記録の created_by を設定して audit も保存する。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) recordAttendanceLog(id string, binding *domain.WorkplaceBindings, action string, timestamp time.Time, audit *domain.AttendanceAudit) (*domain.AttendanceLog, error) {
+	if binding.IsArchived() {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "workplace %q is unsubscribed", binding.Workplace)
+	}
+	// DynamoDB 実装と同じく last_action が無い場合は最新の勤怠記録から状態を求める。
+	// ロックを取っているので、状態の確認と書き込みの間に他の打刻が入ることはない
+	current := binding.LastAction
+	if current == "" {
+		if latest := m.getLatestAttendanceLog(binding.WorkplaceID, binding.UserId); latest != nil {
+			current = latest.Action
+		}
+	}
+	if err := domain.ValidateActionTransition(current, action); err != nil {
+		return nil, err
+	}
+
+	newLog := domain.AttendanceLog{
+		ID:          id,
+		TeamID:      binding.TeamId,
+		UserID:      binding.UserId,
+		Timestamp:   timestamp.UTC().Truncate(time.Millisecond),
+		Action:      action,
+		ChannelID:   binding.CannelId,
+		WorkplaceID: binding.WorkplaceID,
+	}
+	if audit != nil {
+		newLog.CreatedBy = audit.ActorID
+		m.attendanceAudits[audit.ID] = *audit
+	}
+	m.attendanceLogs[id] = newLog
+	binding.LastAction = action
+	m.workplaceBindings[binding.ID] = *binding
+	newLog.WorkplaceName = binding.Workplace
+
+	return &newLog, nil
+}
+
+// resetAttendanceState は DynamoDB 実装と同じく、勤怠記録の編集・削除の後に記録の職場の登録から last_action を消す。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) resetAttendanceState(log *domain.AttendanceLog) {
+	compositeKey := fmt.Sprintf("%s#%s#%s", log.TeamID, log.ChannelID, log.UserID)
+	for id, binding := range m.workplaceBindings {
+		if binding.CompositeKey == compositeKey && binding.WorkplaceID == log.WorkplaceID {
+			binding.LastAction = ""
+			m.workplaceBindings[id] = binding
+		}
+	}
+}
+
+func (m *Memory) DBAddAttendanceLogStart(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return m.addAttendanceLog(id, teamID, channelID, userID, bindingID, action, timestamp)
+}
+
+func (m *Memory) DBAddAttendanceLogEnd(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return m.addAttendanceLog(id, teamID, channelID, userID, bindingID, action, timestamp)
+}
+
+func (m *Memory) DBAddAttendanceLogBreakStart(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return m.addAttendanceLog(id, teamID, channelID, userID, bindingID, action, timestamp)
+}
+
+func (m *Memory) DBAddAttendanceLogBreakEnd(ctx context.Context, id, teamID, channelID, userID, bindingID, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	return m.addAttendanceLog(id, teamID, channelID, userID, bindingID, action, timestamp)
+}
+
+func (m *Memory) DBGetAttendanceLog(ctx context.Context, id string) (*domain.AttendanceLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	log, ok := m.attendanceLogs[id]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+	}
+	log.WorkplaceName = m.workplaceName(log.WorkplaceID)
+
+	return &log, nil
+}
+
+// newWorkplaceBinding は DynamoDB 実装と同じく、登録中の職場と同じ名前でなければ workplace の登録を作る。
+// 呼び出し側でロックを取得していること。
+func (m *Memory) newWorkplaceBinding(id, teamID, channelID, userID string, workplace *domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error) {
+	for _, b := range m.getWorkplaceBindings(teamID, channelID, userID, false) {
+		if b.Workplace == workplace.Name {
+			return nil, domain.ErrAlreadySubscribed
+		}
+	}
+
+	newBinding := domain.WorkplaceBindings{
+		ID:           id,
+		TeamId:       teamID,
+		CannelId:     channelID,
+		UserId:       userID,
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+		DeletedAt:    nil,
+		CompositeKey: fmt.Sprintf("%s#%s#%s", teamID, channelID, userID),
+	}
+	newBinding.SetWorkplace(workplace)
+
+	return &newBinding, nil
+}
+
+func (m *Memory) DBSubscribeWorkplace(ctx context.Context, id, teamID, channelID, userID string, workplace domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newBinding, err := m.newWorkplaceBinding(id, teamID, channelID, userID, &workplace, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	m.workplaces[workplace.ID] = workplace
+	m.workplaceBindings[id] = *newBinding
+
+	return newBinding, nil
+}
+
+func (m *Memory) DBUnsubscribeWorkplace(ctx context.Context, id string, deletedAt time.Time) (*domain.WorkplaceBindings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// DynamoDB 実装の条件付き更新と同じく、登録が無いか解除済みの場合は ErrNotSubscribed とする
+	binding, ok := m.workplaceBindings[id]
+	if !ok || binding.IsArchived() {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
+	}
+	binding.DeletedAt = &deletedAt
+	binding.UpdatedAt = deletedAt
+	m.workplaceBindings[id] = binding
+	binding = m.withWorkplace(binding)
+
+	return &binding, nil
+}
+
+func (m *Memory) DBRenameWorkplace(ctx context.Context, id, name string, updatedAt time.Time) (*domain.Workplace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workplace, ok := m.workplaces[id]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+	workplace.Name = name
+	workplace.UpdatedAt = updatedAt
+	m.workplaces[id] = workplace
+
+	return &workplace, nil
+}
+
+func (m *Memory) DBUpdateWorkplaceSettings(ctx context.Context, id string, settings domain.WorkplaceSettings, updatedAt time.Time) (*domain.Workplace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workplace, ok := m.workplaces[id]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+	workplace.WorkplaceSettings = settings
+	workplace.UpdatedAt = updatedAt
+	m.workplaces[id] = workplace
+
+	return &workplace, nil
+}
+
+func (m *Memory) DBGetAttendanceLogListByUserAndRange(ctx context.Context, teamID, channelID, userID, bindingID string, from, to time.Time) ([]domain.AttendanceLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	binding, err := m.getOwnedWorkplaceBinding(teamID, channelID, userID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]domain.AttendanceLog, 0)
+	for _, log := range m.queryAttendanceLogs(binding.WorkplaceID, binding.UserId) {
+		if !log.Timestamp.Before(from) && log.Timestamp.Before(to) {
+			log.WorkplaceName = binding.Workplace
+			logs = append(logs, log)
+		}
+	}
+
+	return logs, nil
+}
+
+// memoryCursor は DynamoDB の LastEvaluatedKey に相当する、最後に返した記録の位置。
+type memoryCursor struct {
+	Timestamp time.Time `json:"timestamp"`
+	ID        string    `json:"id"`
+}
+
+func (m *Memory) DBGetAttendanceLogPageByUserAndRange(ctx context.Context, teamID, channelID, userID, bindingID string, from, to time.Time, cursor string, limit int) (*domain.AttendanceLogPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	binding, err := m.getOwnedWorkplaceBinding(teamID, channelID, userID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *memoryCursor
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		after = &memoryCursor{}
+		if err := json.Unmarshal(b, after); err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+	}
+
+	page := &domain.AttendanceLogPage{Logs: make([]domain.AttendanceLog, 0)}
+	for _, log := range m.queryAttendanceLogs(binding.WorkplaceID, binding.UserId) {
+		if log.Timestamp.Before(from) || !log.Timestamp.Before(to) {
+			continue
+		}
+		if after != nil && (log.Timestamp.Before(after.Timestamp) || (log.Timestamp.Equal(after.Timestamp) && log.ID <= after.ID)) {
+			continue
+		}
+		log.WorkplaceName = binding.Workplace
+		page.Logs = append(page.Logs, log)
+		if len(page.Logs) == limit {
+			// DynamoDB と同じく、limit 件に達した時点で続きの有無に関係なくカーソルを返す
+			b, err := json.Marshal(memoryCursor{Timestamp: log.Timestamp, ID: log.ID})
+			if err != nil {
+				return nil, err
+			}
+			page.NextCursor = base64.RawURLEncoding.EncodeToString(b)
+			break
+		}
+	}
+
+	return page, nil
+}
+
+func (m *Memory) DBUpdateAttendanceLog(ctx context.Context, teamID, channelID, userID, id string, newTimestamp time.Time) (*domain.AttendanceLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// DynamoDB 実装の条件式と同じく、存在しない項目や他人の項目は ErrForbidden とする
+	log, ok := m.attendanceLogs[id]
+	if !ok || !log.IsOwnedBy(teamID, channelID, userID) {
+		return nil, domain.ErrForbidden
+	}
+	log.Timestamp = newTimestamp.UTC().Truncate(time.Millisecond)
+	log.UpdatedBy = userID
+	m.attendanceLogs[id] = log
+	m.resetAttendanceState(&log)
+	log.WorkplaceName = m.workplaceName(log.WorkplaceID)
+
+	return &log, nil
+}
+
+func (m *Memory) DBDeleteAttendanceLog(ctx context.Context, teamID, channelID, userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	log, ok := m.attendanceLogs[id]
+	if !ok || !log.IsOwnedBy(teamID, channelID, userID) {
+		return domain.ErrForbidden
+	}
+
+	delete(m.attendanceLogs, id)
+	m.resetAttendanceState(&log)
+
+	return nil
+}
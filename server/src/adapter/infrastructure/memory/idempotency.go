@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// DBReserveIdempotencyKey は DynamoDB 実装の条件付き書き込みと同じく、キーが無いか期限切れの場合のみ record を保存して nil を返す。
+// 既に有効な記録がある場合は保存せずにその記録を返す。
+func (m *Memory) DBReserveIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.idempotency[record.Key]; ok && existing.ExpiresAt.After(now) {
+		return &existing, nil
+	}
+	m.idempotency[record.Key] = *record
+
+	return nil, nil
+}
+
+func (m *Memory) DBSaveIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.idempotency[record.Key] = *record
+
+	return nil
+}
+
+func (m *Memory) DBDeleteIdempotencyRecord(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotency, key)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// DBGetWorkplaceAttendanceLogs は DynamoDB 実装と同じく、職場の [from, to) の勤怠記録を時刻順に返す。
+func (m *Memory) DBGetWorkplaceAttendanceLogs(ctx context.Context, workplaceID, userID string, from, to time.Time) ([]domain.AttendanceLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workplace, ok := m.workplaces[workplaceID]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+
+	logs := make([]domain.AttendanceLog, 0)
+	for _, log := range m.queryAttendanceLogs(workplaceID, userID) {
+		if !log.Timestamp.Before(from) && log.Timestamp.Before(to) {
+			log.WorkplaceName = workplace.Name
+			logs = append(logs, log)
+		}
+	}
+
+	return logs, nil
+}
+
+func (m *Memory) DBAddMemberAttendanceLog(ctx context.Context, id, bindingID, action string, timestamp time.Time, audit domain.AttendanceAudit) (*domain.AttendanceLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	binding, ok := m.workplaceBindings[bindingID]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
+	}
+	binding = m.withWorkplace(binding)
+
+	return m.recordAttendanceLog(id, &binding, action, timestamp, &audit)
+}
+
+// DBUpdateMemberAttendanceLog は DynamoDB 実装の条件式と同じく、職場 workplaceID の記録でなければ ErrNotFound を返す。
+func (m *Memory) DBUpdateMemberAttendanceLog(ctx context.Context, workplaceID, id string, newTimestamp time.Time, audit domain.AttendanceAudit) (*domain.AttendanceLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	log, ok := m.attendanceLogs[id]
+	if !ok || log.WorkplaceID != workplaceID {
+		return nil, domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+	}
+	log.Timestamp = newTimestamp.UTC().Truncate(time.Millisecond)
+	log.UpdatedBy = audit.ActorID
+	m.attendanceLogs[id] = log
+	m.attendanceAudits[audit.ID] = audit
+	m.resetAttendanceState(&log)
+	log.WorkplaceName = m.workplaceName(log.WorkplaceID)
+
+	return &log, nil
+}
+
+func (m *Memory) DBDeleteMemberAttendanceLog(ctx context.Context, workplaceID, id string, audit domain.AttendanceAudit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	log, ok := m.attendanceLogs[id]
+	if !ok || log.WorkplaceID != workplaceID {
+		return domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+	}
+	delete(m.attendanceLogs, id)
+	m.attendanceAudits[audit.ID] = audit
+	m.resetAttendanceState(&log)
+
+	return nil
+}
+
+// DBGetAttendanceAudits は WorkplaceID-index への Query と同じく、職場の [from, to) の監査ログを古い順に返す。
+func (m *Memory) DBGetAttendanceAudits(ctx context.Context, workplaceID string, from, to time.Time) ([]domain.AttendanceAudit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	audits := make([]domain.AttendanceAudit, 0)
+	for _, audit := range m.attendanceAudits {
+		if audit.WorkplaceID == workplaceID && !audit.CreatedAt.Before(from) && audit.CreatedAt.Before(to) {
+			audits = append(audits, audit)
+		}
+	}
+	sort.Slice(audits, func(a, b int) bool {
+		if !audits[a].CreatedAt.Equal(audits[b].CreatedAt) {
+			return audits[a].CreatedAt.Before(audits[b].CreatedAt)
+		}
+		return audits[a].ID < audits[b].ID
+	})
+
+	return audits, nil
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+	"github.com/yuorei/attendance/src/usecase/port"
+)
+
+var (
+	_ port.AttendanceLogRepository = (*Memory)(nil)
+	_ port.OAuthStateRepository    = (*Memory)(nil)
+	_ port.SlackTokenRepository    = (*Memory)(nil)
+	_ port.IdempotencyRepository   = (*Memory)(nil)
+)
+
+// Memory はリポジトリのインメモリ実装。
+// DynamoDB を使わずにテストやオフライン開発を行うためのもの。
+type Memory struct {
+	mu                sync.Mutex
+	workplaces        map[string]domain.Workplace
+	workplaceBindings map[string]domain.WorkplaceBindings
+	attendanceLogs    map[string]domain.AttendanceLog
+	attendanceAudits  map[string]domain.AttendanceAudit
+	oauthStates       map[string]time.Time
+	slackTokens       map[string]domain.SlackToken
+	idempotency       map[string]domain.IdempotencyRecord
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		workplaces:        make(map[string]domain.Workplace),
+		workplaceBindings: make(map[string]domain.WorkplaceBindings),
+		attendanceLogs:    make(map[string]domain.AttendanceLog),
+		attendanceAudits:  make(map[string]domain.AttendanceAudit),
+		oauthStates:       make(map[string]time.Time),
+		slackTokens:       make(map[string]domain.SlackToken),
+		idempotency:       make(map[string]domain.IdempotencyRecord),
+	}
+}
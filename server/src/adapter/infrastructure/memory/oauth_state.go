@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+func (m *Memory) DBSaveOAuthState(ctx context.Context, id string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.oauthStates[id] = expiresAt
+
+	return nil
+}
+
+// DBDeleteOAuthState は DynamoDB 実装の条件付き削除と同じく、期限内の state だけを削除する。
+func (m *Memory) DBDeleteOAuthState(ctx context.Context, id string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiresAt, ok := m.oauthStates[id]
+	if !ok || !expiresAt.After(now) {
+		return domain.ErrOAuthStateNotFound
+	}
+	delete(m.oauthStates, id)
+
+	return nil
+}
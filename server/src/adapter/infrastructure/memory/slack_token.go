@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+func (m *Memory) DBSaveSlackToken(ctx context.Context, token *domain.SlackToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.slackTokens[domain.SlackTokenID(token.TeamID, token.UserID)] = *token
+
+	return nil
+}
+
+func (m *Memory) DBGetSlackToken(ctx context.Context, teamID, userID string) (*domain.SlackToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.slackTokens[domain.SlackTokenID(teamID, userID)]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "SlackToken not found")
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+func (m *Memory) DBCreateWorkplace(ctx context.Context, workplace domain.Workplace) (*domain.Workplace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.workplaces[workplace.ID]; ok {
+		return nil, domain.NewError(domain.ErrConflict, "Workplace %s already exists", workplace.ID)
+	}
+	m.workplaces[workplace.ID] = workplace
+
+	return &workplace, nil
+}
+
+// DBGetTeamWorkplaces は DynamoDB 実装と同じく、チームの職場だけを作成順に返す。
+func (m *Memory) DBGetTeamWorkplaces(ctx context.Context, teamID string) ([]domain.Workplace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workplaces := make([]domain.Workplace, 0)
+	for _, workplace := range m.workplaces {
+		if workplace.TeamID == teamID && workplace.Shared {
+			workplaces = append(workplaces, workplace)
+		}
+	}
+	sort.SliceStable(workplaces, func(a, b int) bool {
+		if !workplaces[a].CreatedAt.Equal(workplaces[b].CreatedAt) {
+			return workplaces[a].CreatedAt.Before(workplaces[b].CreatedAt)
+		}
+		return workplaces[a].ID < workplaces[b].ID
+	})
+
+	return workplaces, nil
+}
+
+func (m *Memory) DBJoinWorkplace(ctx context.Context, id, teamID, channelID, userID string, workplace domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newBinding, err := m.newWorkplaceBinding(id, teamID, channelID, userID, &workplace, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	m.workplaceBindings[id] = *newBinding
+
+	return newBinding, nil
+}
+
+// DBGetWorkplaceMembers は WorkplaceID-index への Query と同じく workplace_id で検索し、登録中の登録を登録順に返す。
+// DynamoDB 実装と同じく、last_action が無い登録は最新の勤怠記録から LastAction を求める。
+func (m *Memory) DBGetWorkplaceMembers(ctx context.Context, workplaceID string) ([]domain.WorkplaceBindings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.workplaces[workplaceID]; !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+
+	members := make([]domain.WorkplaceBindings, 0)
+	for _, binding := range m.workplaceBindings {
+		if binding.WorkplaceID != workplaceID || binding.IsArchived() {
+			continue
+		}
+		if binding.LastAction == "" {
+			if latest := m.getLatestAttendanceLog(workplaceID, binding.UserId); latest != nil {
+				binding.LastAction = latest.Action
+			}
+		}
+		members = append(members, m.withWorkplace(binding))
+	}
+	sort.SliceStable(members, func(a, b int) bool {
+		if !members[a].CreatedAt.Equal(members[b].CreatedAt) {
+			return members[a].CreatedAt.Before(members[b].CreatedAt)
+		}
+		return members[a].ID < members[b].ID
+	})
+
+	return members, nil
+}
+
+func (m *Memory) DBUpdateWorkplaceRoles(ctx context.Context, workplaceID string, roles map[string]domain.WorkplaceRole, updatedAt time.Time) (*domain.Workplace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workplace, ok := m.workplaces[workplaceID]
+	if !ok {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+	workplace.Roles = roles
+	workplace.UpdatedAt = updatedAt
+	m.workplaces[workplaceID] = workplace
+
+	return &workplace, nil
+}
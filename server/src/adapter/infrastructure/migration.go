@@ -0,0 +1,134 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// legacyTimestampLayout は time.Time.String() で保存されていた旧形式のレイアウト。
+const legacyTimestampLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// legacyMonotonicSuffix は time.Time.String() が付与する単調時計の表記 " m=+..." にマッチする。
+var legacyMonotonicSuffix = regexp.MustCompile(` m=[+-].*$`)
+
+// TimestampMigrationFailure は移行できなかった項目とその理由。
+type TimestampMigrationFailure struct {
+	ID        string
+	Timestamp string
+	Err       error
+}
+
+// TimestampMigrationResult は移行処理の集計結果。
+type TimestampMigrationResult struct {
+	Scanned  int
+	Migrated int
+	Skipped  int
+	Failures []TimestampMigrationFailure
+}
+
+// parseLegacyTimestamp は旧形式・新形式どちらの timestamp も time.Time に変換する。
+func parseLegacyTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	cleaned := legacyMonotonicSuffix.ReplaceAllString(value, "")
+	return time.Parse(legacyTimestampLayout, cleaned)
+}
+
+// MigrateAttendanceLogTimestamps は AttendanceLog テーブルを全件スキャンし、
+// timestamp 属性を domain.TimestampLayout の形式に書き換える。
+// 1ページ処理するごとに progress が呼ばれる。dryRun が true の場合は書き込みを行わない。
+func (i *Infrastructure) MigrateAttendanceLogTimestamps(ctx context.Context, dryRun bool, progress func(TimestampMigrationResult)) (*TimestampMigrationResult, error) {
+	result := &TimestampMigrationResult{}
+
+	var startKey map[string]types.AttributeValue
+	for {
+		output, err := i.db.Database.Scan(ctx, &dynamodb.ScanInput{
+			TableName:                aws.String(tableAttendanceLog),
+			ProjectionExpression:     aws.String("id, #ts"),
+			ExpressionAttributeNames: map[string]string{"#ts": "timestamp"},
+			ExclusiveStartKey:        startKey,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to scan AttendanceLog: %w", err)
+		}
+
+		for _, item := range output.Items {
+			result.Scanned++
+			i.migrateAttendanceLogTimestamp(ctx, item, dryRun, result)
+		}
+
+		if progress != nil {
+			progress(*result)
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = output.LastEvaluatedKey
+	}
+
+	return result, nil
+}
+
+func (i *Infrastructure) migrateAttendanceLogTimestamp(ctx context.Context, item map[string]types.AttributeValue, dryRun bool, result *TimestampMigrationResult) {
+	idAttr, ok := item["id"].(*types.AttributeValueMemberS)
+	if !ok {
+		result.Failures = append(result.Failures, TimestampMigrationFailure{Err: errors.New("id attribute is missing")})
+		return
+	}
+	tsAttr, ok := item["timestamp"].(*types.AttributeValueMemberS)
+	if !ok {
+		result.Failures = append(result.Failures, TimestampMigrationFailure{ID: idAttr.Value, Err: errors.New("timestamp attribute is missing")})
+		return
+	}
+
+	t, err := parseLegacyTimestamp(tsAttr.Value)
+	if err != nil {
+		result.Failures = append(result.Failures, TimestampMigrationFailure{ID: idAttr.Value, Timestamp: tsAttr.Value, Err: err})
+		return
+	}
+
+	newValue := domain.FormatTimestamp(t)
+	if newValue == tsAttr.Value {
+		result.Skipped++
+		return
+	}
+
+	if dryRun {
+		result.Migrated++
+		return
+	}
+
+	// 移行中に編集された項目を上書きしないよう、読み込んだ値から変わっていない場合のみ更新する
+	_, err = i.db.Database.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableAttendanceLog),
+		Key: map[string]types.AttributeValue{
+			"id": idAttr,
+		},
+		UpdateExpression:    aws.String("SET #ts = :new"),
+		ConditionExpression: aws.String("#ts = :old"),
+		ExpressionAttributeNames: map[string]string{
+			"#ts": "timestamp",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":new": &types.AttributeValueMemberS{Value: newValue},
+			":old": tsAttr,
+		},
+	})
+	if err != nil {
+		result.Failures = append(result.Failures, TimestampMigrationFailure{ID: idAttr.Value, Timestamp: tsAttr.Value, Err: err})
+		return
+	}
+
+	result.Migrated++
+}
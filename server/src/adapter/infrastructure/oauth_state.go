@@ -0,0 +1,66 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+var tableOAuthStates = "OAuthStates-" + os.Getenv("ENV")
+
+// oauthStateItem は OAuthStates テーブルに保存する項目。
+// expires_at は DynamoDB の TTL で期限切れの項目を削除するため、UNIX 時間（秒）で保存する。
+type oauthStateItem struct {
+	ID        string `dynamodbav:"id"`
+	ExpiresAt int64  `dynamodbav:"expires_at"`
+}
+
+func (i *Infrastructure) DBSaveOAuthState(ctx context.Context, id string, expiresAt time.Time) error {
+	item, err := marshalMap(oauthStateItem{ID: id, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal OAuthState: %w", err)
+	}
+
+	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(tableOAuthStates),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save OAuthState: %w", err)
+	}
+
+	return nil
+}
+
+// DBDeleteOAuthState は期限内の state を条件付きで削除する。同じ state のコールバックが同時に届いても、削除できるのは1つだけ。
+// TTL による削除はすぐには行われないため、期限も条件に含める。
+func (i *Infrastructure) DBDeleteOAuthState(ctx context.Context, id string, now time.Time) error {
+	_, err := i.db.Database.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableOAuthStates),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("attribute_exists(id) AND expires_at > :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		var conditionalCheckFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionalCheckFailed) {
+			return domain.ErrOAuthStateNotFound
+		}
+		return fmt.Errorf("failed to delete OAuthState: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// queryAll は LastEvaluatedKey が無くなるまで Query を繰り返し、すべての項目を返す。
+// 1回の Query は 1MB までしか返さないため、複数の項目を読むときは必ずこれか queryPage を使う。
+func (i *Infrastructure) queryAll(ctx context.Context, input *dynamodb.QueryInput) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+	for {
+		output, err := i.db.Database.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, output.Items...)
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// queryPage は cursor の位置から最大 limit 件の項目を取得し、続きがあれば次のカーソルを返す。
+// cursor が空の場合は先頭から取得する。続きが無い場合、次のカーソルは空文字になる。
+func (i *Infrastructure) queryPage(ctx context.Context, input *dynamodb.QueryInput, cursor string, limit int32) ([]map[string]types.AttributeValue, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	input.ExclusiveStartKey = startKey
+	input.Limit = aws.Int32(limit)
+
+	output, err := i.db.Database.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next, err := encodeCursor(output.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return output.Items, next, nil
+}
+
+// cursorKey はカーソルに入れるキー属性。DynamoDB のキーは文字列か数値なので、型と値だけを持つ。
+type cursorKey struct {
+	S *string `json:"s,omitempty"`
+	N *string `json:"n,omitempty"`
+}
+
+// encodeCursor は LastEvaluatedKey を API で受け渡す不透明な文字列にする。key が空の場合は空文字を返す。
+func encodeCursor(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	keys := make(map[string]cursorKey, len(key))
+	for name, value := range key {
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			keys[name] = cursorKey{S: aws.String(v.Value)}
+		case *types.AttributeValueMemberN:
+			keys[name] = cursorKey{N: aws.String(v.Value)}
+		default:
+			return "", fmt.Errorf("unsupported key attribute type %T for %s", value, name)
+		}
+	}
+
+	b, err := json.Marshal(keys)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor は encodeCursor で作ったカーソルを ExclusiveStartKey に戻す。cursor が空の場合は nil を返す。
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var keys map[string]cursorKey
+	if err := json.Unmarshal(b, &keys); err != nil || len(keys) == 0 {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	key := make(map[string]types.AttributeValue, len(keys))
+	for name, k := range keys {
+		switch {
+		case k.S != nil:
+			key[name] = &types.AttributeValueMemberS{Value: *k.S}
+		case k.N != nil:
+			key[name] = &types.AttributeValueMemberN{Value: *k.N}
+		default:
+			return nil, domain.ErrInvalidCursor
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,129 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+var tableSlackTokens = "SlackTokens-" + os.Getenv("ENV")
+
+// slackTokenItem は SlackTokens テーブルに保存する項目。トークンは暗号化した状態で保持する。
+type slackTokenItem struct {
+	ID                 string    `dynamodbav:"id"`
+	TeamID             string    `dynamodbav:"team_id"`
+	UserID             string    `dynamodbav:"user_id"`
+	EncryptedBotToken  []byte    `dynamodbav:"encrypted_bot_token"`
+	EncryptedUserToken []byte    `dynamodbav:"encrypted_user_token"`
+	Scope              string    `dynamodbav:"scope"`
+	UpdatedAt          time.Time `dynamodbav:"updated_at"`
+}
+
+func (i *Infrastructure) encryptToken(id, token string) ([]byte, error) {
+	if i.tokenCipher == nil {
+		return nil, fmt.Errorf("token encryption is not configured: %w", i.tokenCipherErr)
+	}
+	if token == "" {
+		return nil, nil
+	}
+
+	// 暗号文を別の項目にコピーしても復号できないよう、項目の id を追加データに含める
+	return i.tokenCipher.Encrypt([]byte(token), []byte(id))
+}
+
+func (i *Infrastructure) decryptToken(id string, ciphertext []byte) (string, error) {
+	if i.tokenCipher == nil {
+		return "", fmt.Errorf("token encryption is not configured: %w", i.tokenCipherErr)
+	}
+	if len(ciphertext) == 0 {
+		return "", nil
+	}
+
+	plaintext, err := i.tokenCipher.Decrypt(ciphertext, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt SlackToken: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func (i *Infrastructure) DBSaveSlackToken(ctx context.Context, token *domain.SlackToken) error {
+	id := domain.SlackTokenID(token.TeamID, token.UserID)
+	encryptedBotToken, err := i.encryptToken(id, token.BotToken)
+	if err != nil {
+		return err
+	}
+	encryptedUserToken, err := i.encryptToken(id, token.UserToken)
+	if err != nil {
+		return err
+	}
+
+	item, err := marshalMap(slackTokenItem{
+		ID:                 id,
+		TeamID:             token.TeamID,
+		UserID:             token.UserID,
+		EncryptedBotToken:  encryptedBotToken,
+		EncryptedUserToken: encryptedUserToken,
+		Scope:              token.Scope,
+		UpdatedAt:          token.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal SlackToken: %w", err)
+	}
+
+	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableSlackTokens),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save SlackToken: %w", err)
+	}
+
+	return nil
+}
+
+func (i *Infrastructure) DBGetSlackToken(ctx context.Context, teamID, userID string) (*domain.SlackToken, error) {
+	id := domain.SlackTokenID(teamID, userID)
+	output, err := i.db.Database.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableSlackTokens),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SlackToken: %w", err)
+	}
+	if output.Item == nil {
+		return nil, domain.NewError(domain.ErrNotFound, "SlackToken not found")
+	}
+
+	var item slackTokenItem
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal SlackToken: %w", err)
+	}
+
+	botToken, err := i.decryptToken(id, item.EncryptedBotToken)
+	if err != nil {
+		return nil, err
+	}
+	userToken, err := i.decryptToken(id, item.EncryptedUserToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SlackToken{
+		TeamID:    item.TeamID,
+		UserID:    item.UserID,
+		BotToken:  botToken,
+		UserToken: userToken,
+		Scope:     item.Scope,
+		UpdatedAt: item.UpdatedAt,
+	}, nil
+}
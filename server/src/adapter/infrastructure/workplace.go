@@ -0,0 +1,147 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// DBCreateWorkplace はチームの職場を作る。職場名の重複は呼び出し側で確認する。
+func (i *Infrastructure) DBCreateWorkplace(ctx context.Context, workplace domain.Workplace) (*domain.Workplace, error) {
+	item, err := marshalMap(workplace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Workplace: %w", err)
+	}
+
+	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(tableWorkplaces),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save Workplace: %w", err)
+	}
+
+	return &workplace, nil
+}
+
+// DBGetTeamWorkplaces は TeamID-index を team_id で検索し、チームの職場だけを作成順に返す。
+func (i *Infrastructure) DBGetTeamWorkplaces(ctx context.Context, teamID string) ([]domain.Workplace, error) {
+	items, err := i.queryAll(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(tableWorkplaces),
+		IndexName:              aws.String(indexTeamID),
+		KeyConditionExpression: aws.String("team_id = :teamId"),
+		// 個人の職場も同じ team_id を持つため、チームの職場に絞り込む
+		FilterExpression: aws.String("shared = :shared"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":teamId": &types.AttributeValueMemberS{Value: teamID},
+			":shared": &types.AttributeValueMemberBOOL{Value: true},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Workplaces: %w", err)
+	}
+
+	workplaces := make([]domain.Workplace, 0, len(items))
+	if err := attributevalue.UnmarshalListOfMaps(items, &workplaces); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Workplaces: %w", err)
+	}
+	sort.SliceStable(workplaces, func(a, b int) bool {
+		return workplaces[a].CreatedAt.Before(workplaces[b].CreatedAt)
+	})
+
+	return workplaces, nil
+}
+
+// DBJoinWorkplace は既存の職場 workplace を呼び出し元のチャンネルに登録する。
+func (i *Infrastructure) DBJoinWorkplace(ctx context.Context, id, teamID, channelID, userID string, workplace domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error) {
+	newBinding, err := i.newWorkplaceBinding(ctx, id, teamID, channelID, userID, &workplace, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := marshalMap(newBinding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal WorkplaceBinding: %w", err)
+	}
+	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(tableWorkplaceBindings),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save WorkplaceBinding: %w", err)
+	}
+
+	return newBinding, nil
+}
+
+// DBGetWorkplaceMembers は WorkplaceID-index を workplace_id で検索し、職場に登録中の登録を登録順に返す。
+// 登録を解除したユーザーは含めない。勤怠記録の編集などで last_action が無い登録は、最新の勤怠記録から LastAction を求める。
+func (i *Infrastructure) DBGetWorkplaceMembers(ctx context.Context, workplaceID string) ([]domain.WorkplaceBindings, error) {
+	workplace, err := i.getWorkplace(ctx, workplaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := i.queryAll(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(tableWorkplaceBindings),
+		IndexName:              aws.String(indexWorkplaceID),
+		KeyConditionExpression: aws.String("workplace_id = :workplaceId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":workplaceId": &types.AttributeValueMemberS{Value: workplaceID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WorkplaceBinding: %w", err)
+	}
+	var all []domain.WorkplaceBindings
+	if err := attributevalue.UnmarshalListOfMaps(items, &all); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal WorkplaceBinding: %w", err)
+	}
+
+	members := make([]domain.WorkplaceBindings, 0, len(all))
+	for _, b := range all {
+		if b.IsArchived() {
+			continue
+		}
+		if b.LastAction == "" {
+			latestLog, err := i.getLatestAttendanceLog(ctx, workplaceID, b.UserId)
+			if err != nil {
+				return nil, err
+			}
+			if latestLog != nil {
+				b.LastAction = latestLog.Action
+			}
+		}
+		b.SetWorkplace(workplace)
+		members = append(members, b)
+	}
+	sort.SliceStable(members, func(a, b int) bool {
+		return members[a].CreatedAt.Before(members[b].CreatedAt)
+	})
+
+	return members, nil
+}
+
+// DBUpdateWorkplaceRoles は職場のユーザーごとの役割を roles に置き換える。
+func (i *Infrastructure) DBUpdateWorkplaceRoles(ctx context.Context, workplaceID string, roles map[string]domain.WorkplaceRole, updatedAt time.Time) (*domain.Workplace, error) {
+	value, err := attributevalue.Marshal(roles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Workplace roles: %w", err)
+	}
+
+	return i.updateWorkplace(ctx, workplaceID, "SET #roles = :roles, updated_at = :updatedAt", map[string]string{
+		"#roles": "roles",
+	}, map[string]types.AttributeValue{
+		":roles":     value,
+		":updatedAt": &types.AttributeValueMemberS{Value: domain.FormatTimestamp(updatedAt)},
+	})
+}
@@ -0,0 +1,139 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// WorkplaceMigrationFailure は移行できなかった職場の登録とその理由。
+type WorkplaceMigrationFailure struct {
+	ID  string
+	Err error
+}
+
+// WorkplaceMigrationResult は移行処理の集計結果。
+type WorkplaceMigrationResult struct {
+	Scanned  int
+	Migrated int
+	Skipped  int
+	Failures []WorkplaceMigrationFailure
+}
+
+// legacyWorkplaceBinding は職場名と設定を WorkplaceBindings の項目に直接持っていた旧形式の登録。
+type legacyWorkplaceBinding struct {
+	ID          string `dynamodbav:"id"`
+	TeamID      string `dynamodbav:"team_id"`
+	UserID      string `dynamodbav:"user_id"`
+	WorkplaceID string `dynamodbav:"workplace_id"`
+	Workplace   string `dynamodbav:"workplace"`
+	domain.WorkplaceSettings
+	CreatedAt time.Time `dynamodbav:"created_at"`
+	UpdatedAt time.Time `dynamodbav:"updated_at"`
+}
+
+// MigrateWorkplaceBindings は WorkplaceBindings テーブルを全件スキャンし、workplace_id を持たない旧形式の登録ごとに
+// 職場名と設定を移した Workplace を作って workplace_id で参照させる。
+// 既存の勤怠記録の workplace_id は登録の ID なので、作る Workplace の ID も登録の ID と同じにする。
+// 作る職場は登録したユーザーの個人の職場になる。
+// 1ページ処理するごとに progress が呼ばれる。dryRun が true の場合は書き込みを行わない。
+func (i *Infrastructure) MigrateWorkplaceBindings(ctx context.Context, dryRun bool, progress func(WorkplaceMigrationResult)) (*WorkplaceMigrationResult, error) {
+	result := &WorkplaceMigrationResult{}
+
+	var startKey map[string]types.AttributeValue
+	for {
+		output, err := i.db.Database.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(tableWorkplaceBindings),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to scan WorkplaceBindings: %w", err)
+		}
+
+		for _, item := range output.Items {
+			result.Scanned++
+			i.migrateWorkplaceBinding(ctx, item, dryRun, result)
+		}
+
+		if progress != nil {
+			progress(*result)
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = output.LastEvaluatedKey
+	}
+
+	return result, nil
+}
+
+func (i *Infrastructure) migrateWorkplaceBinding(ctx context.Context, item map[string]types.AttributeValue, dryRun bool, result *WorkplaceMigrationResult) {
+	var legacy legacyWorkplaceBinding
+	if err := attributevalue.UnmarshalMap(item, &legacy); err != nil {
+		result.Failures = append(result.Failures, WorkplaceMigrationFailure{Err: err})
+		return
+	}
+	if legacy.ID == "" {
+		result.Failures = append(result.Failures, WorkplaceMigrationFailure{Err: errors.New("id attribute is missing")})
+		return
+	}
+	if legacy.WorkplaceID != "" {
+		result.Skipped++
+		return
+	}
+
+	if dryRun {
+		result.Migrated++
+		return
+	}
+
+	workplace := domain.NewWorkplace(legacy.ID, legacy.TeamID, legacy.UserID, legacy.Workplace, legacy.WorkplaceSettings, legacy.CreatedAt)
+	workplace.UpdatedAt = legacy.UpdatedAt
+	workplaceItem, err := marshalMap(workplace)
+	if err != nil {
+		result.Failures = append(result.Failures, WorkplaceMigrationFailure{ID: legacy.ID, Err: err})
+		return
+	}
+
+	// 途中で中断して再実行した場合に備え、Workplace が作成済みでも登録の更新は続ける
+	_, err = i.db.Database.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(tableWorkplaces),
+		Item:                workplaceItem,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &conditionalCheckFailed) {
+		result.Failures = append(result.Failures, WorkplaceMigrationFailure{ID: legacy.ID, Err: err})
+		return
+	}
+
+	_, err = i.db.Database.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableWorkplaceBindings),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: legacy.ID},
+		},
+		UpdateExpression:    aws.String("SET workplace_id = :id"),
+		ConditionExpression: aws.String("attribute_not_exists(workplace_id)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":id": &types.AttributeValueMemberS{Value: legacy.ID},
+		},
+	})
+	if err != nil {
+		if errors.As(err, &conditionalCheckFailed) {
+			result.Skipped++
+			return
+		}
+		result.Failures = append(result.Failures, WorkplaceMigrationFailure{ID: legacy.ID, Err: err})
+		return
+	}
+
+	result.Migrated++
+}
@@ -0,0 +1,113 @@
+package presentation
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// ErrorResponse は API のエラー応答。Error はクライアントが分岐に使うエラーコード。
+// Candidates は職場を特定できなかった場合に、workplace に指定できる職場名。
+type ErrorResponse struct {
+	Error      string   `json:"error"`
+	Message    string   `json:"message"`
+	Candidates []string `json:"candidates,omitempty"`
+	Success    bool     `json:"success"`
+}
+
+// errorMappings はドメインのエラーと HTTP のステータスコード・エラーコードの対応。
+// 上から順に errors.Is で判定する。
+var errorMappings = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{domain.ErrValidation, http.StatusBadRequest, "validation_error"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrAmbiguousWorkplace, http.StatusBadRequest, "ambiguous_workplace"},
+	{domain.ErrNotSubscribed, http.StatusNotFound, "not_subscribed"},
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrAlreadySubscribed, http.StatusConflict, "already_subscribed"},
+	{domain.ErrAlreadyCheckedIn, http.StatusConflict, "already_checked_in"},
+	{domain.ErrNotCheckedIn, http.StatusConflict, "not_checked_in"},
+	{domain.ErrOnBreak, http.StatusConflict, "on_break"},
+	{domain.ErrNotOnBreak, http.StatusConflict, "not_on_break"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+	{domain.ErrIdempotencyInProgress, http.StatusConflict, "idempotency_in_progress"},
+	{domain.ErrIdempotencyKeyMismatch, http.StatusUnprocessableEntity, "idempotency_key_mismatch"},
+}
+
+// HTTPErrorHandler はハンドラーが返したエラーを、ステータスコードとエラーコード付きの JSON に変換する。
+// ドメインのエラーは 4xx に、それ以外は 500 にする。
+func (h *Handler) HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, code, message := errorStatus(err)
+	if status >= http.StatusInternalServerError {
+		log.Printf("Error: %s %s: %v", c.Request().Method, c.Request().URL.Path, err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		response := ErrorResponse{
+			Error:   code,
+			Message: message,
+			Success: false,
+		}
+		var ambiguous *domain.AmbiguousWorkplaceError
+		if errors.As(err, &ambiguous) {
+			response.Candidates = ambiguous.Candidates
+		}
+		err = c.JSON(status, response)
+	}
+	if err != nil {
+		log.Printf("Error: failed to write error response: %v", err)
+	}
+}
+
+// errorStatus は err に対応するステータスコード、エラーコード、メッセージを返す。
+func errorStatus(err error) (int, string, string) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.status, m.code, err.Error()
+		}
+	}
+
+	// ルーティングやバインドの失敗など Echo が返すエラー
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message := http.StatusText(httpErr.Code)
+		if m, ok := httpErr.Message.(string); ok {
+			message = m
+		}
+		return httpErr.Code, httpErrorCode(httpErr.Code), message
+	}
+
+	return http.StatusInternalServerError, "internal_error", err.Error()
+}
+
+// httpErrorCode はステータスコードから "not_found" のようなエラーコードを作る。
+func httpErrorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+// validationError は入力値が不正なことを表すエラーを返す。
+func validationError(message string) error {
+	return domain.NewError(domain.ErrValidation, "%s", message)
+}
+
+// forbiddenError は呼び出し元に権限が無いことを表すエラーを返す。
+func forbiddenError(message string) error {
+	return domain.NewError(domain.ErrForbidden, "%s", message)
+}
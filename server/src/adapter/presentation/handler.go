@@ -2,54 +2,120 @@ package presentation
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/yuorei/attendance/src/domain"
 	"github.com/yuorei/attendance/src/usecase"
+	"github.com/yuorei/attendance/src/usecase/port"
 )
 
 type Handler struct {
-	usecase *usecase.UseCase
+	usecase            *usecase.UseCase
+	sessionSecret      []byte
+	slackSigningSecret string
+	// slackBaseURL は Slack の OAuth / Web API のベースURL。テストではローカルのスタブを指定する。
+	slackBaseURL string
+	httpClient   *http.Client
+	clock        port.Clock
 }
 
-func NewHandler(repository *usecase.Repository) *Handler {
+const defaultSlackBaseURL = "https://slack.com"
+
+func NewHandler(repository *usecase.Repository, clock port.Clock) *Handler {
 	return &Handler{
-		usecase: usecase.NewUseCase(repository),
+		usecase:            usecase.NewUseCase(repository),
+		sessionSecret:      []byte(os.Getenv("SESSION_SECRET")),
+		slackSigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
+		slackBaseURL:       slackBaseURL(),
+		httpClient:         &http.Client{Timeout: 10 * time.Second},
+		clock:              clock,
+	}
+}
+
+// slackBaseURL は SLACK_BASE_URL 環境変数があればそれを、なければ https://slack.com を返す。
+func slackBaseURL() string {
+	if u := os.Getenv("SLACK_BASE_URL"); u != "" {
+		return strings.TrimRight(u, "/")
 	}
+
+	return defaultSlackBaseURL
 }
 
 func (h *Handler) HealthCheck(c echo.Context) error {
 	return c.String(http.StatusOK, "OK")
 }
 
+// TeamID, UserID は省略可能。指定する場合はセッションの本人と一致している必要がある。
 type CheckInRequest struct {
-	TeamID    string `json:"team_id" validate:"required"`
+	TeamID    string `json:"team_id"`
 	ChannelID string `json:"channel_id" validate:"required"`
-	UserID    string `json:"user_id" validate:"required"`
+	UserID    string `json:"user_id"`
+	Workplace string `json:"workplace"` // 職場名。チャンネルに職場が複数ある場合に指定する
 }
 
+// TeamID, UserID は省略可能。指定する場合はセッションの本人と一致している必要がある。
 type CheckOutRequest struct {
-	TeamID    string `json:"team_id" validate:"required"`
+	TeamID    string `json:"team_id"`
+	ChannelID string `json:"channel_id" validate:"required"`
+	UserID    string `json:"user_id"`
+	Workplace string `json:"workplace"` // 職場名。チャンネルに職場が複数ある場合に指定する
+}
+
+// TeamID, UserID は省略可能。指定する場合はセッションの本人と一致している必要がある。
+type BreakRequest struct {
+	TeamID    string `json:"team_id"`
 	ChannelID string `json:"channel_id" validate:"required"`
-	UserID    string `json:"user_id" validate:"required"`
+	UserID    string `json:"user_id"`
+	Workplace string `json:"workplace"` // 職場名。チャンネルに職場が複数ある場合に指定する
 }
 
+// TeamID, UserID は省略可能。指定する場合はセッションの本人と一致している必要がある。
 type SubscribeWorkplaceRequest struct {
-	TeamID        string `json:"team_id" validate:"required"`
+	TeamID        string `json:"team_id"`
 	ChannelID     string `json:"channel_id" validate:"required"`
-	UserID        string `json:"user_id" validate:"required"`
+	UserID        string `json:"user_id"`
 	WorkplaceName string `json:"workplace_name" validate:"required"`
+	Timezone      string `json:"timezone"` // IANA タイムゾーン名。省略時は Asia/Tokyo
+}
+
+type UnsubscribeWorkplaceRequest struct {
+	ChannelID string `json:"channel_id" validate:"required"`
+	Workplace string `json:"workplace"` // 職場名。チャンネルに職場が複数ある場合に指定する
+}
+
+type RenameWorkplaceRequest struct {
+	ChannelID string `json:"channel_id" validate:"required"`
+	Workplace string `json:"workplace"` // 職場名。チャンネルに職場が複数ある場合に指定する
+	NewName   string `json:"new_name" validate:"required"`
+}
+
+// Settings は設定名と値の組。指定しなかった設定は変更しない。
+type UpdateWorkplaceSettingsRequest struct {
+	ChannelID string            `json:"channel_id" validate:"required"`
+	Workplace string            `json:"workplace"` // 職場名。チャンネルに職場が複数ある場合に指定する
+	Settings  map[string]string `json:"settings" validate:"required"`
+}
+
+// EffectiveFrom は職場のタイムゾーンでの適用開始日 (YYYY-MM-DD)。省略時は今日から適用する。
+type SetHourlyWageRequest struct {
+	ChannelID     string `json:"channel_id" validate:"required"`
+	Workplace     string `json:"workplace"` // 職場名。チャンネルに職場が複数ある場合に指定する
+	HourlyWage    int64  `json:"hourly_wage" validate:"required"`
+	EffectiveFrom string `json:"effective_from"`
 }
 
 type EditAttendanceRequest struct {
 	ID          string `json:"id" validate:"required"`
+	ChannelID   string `json:"channel_id" validate:"required"`
 	NewDateTime string `json:"new_datetime" validate:"required"`
 }
 
@@ -65,33 +131,180 @@ type WorkplaceResponse struct {
 	Success          bool                      `json:"success"`
 }
 
-type MonthlyHoursResponse struct {
+// ArchivedWorkplaces は登録を解除した職場。職場名を指定すれば勤怠記録を取得できる。
+type WorkplacesResponse struct {
+	Workplaces         []domain.WorkplaceBindings `json:"workplaces"`
+	ArchivedWorkplaces []domain.WorkplaceBindings `json:"archived_workplaces"`
+	Message            string                     `json:"message"`
+	Success            bool                       `json:"success"`
+}
+
+// UnmatchedLogResponse は対になる打刻が無く、集計に含めなかった記録。
+type UnmatchedLogResponse struct {
+	AttendanceLog domain.AttendanceLog   `json:"attendance_log"`
+	Reason        domain.UnmatchedReason `json:"reason"` // missing_end / missing_start
+}
+
+func newUnmatchedLogResponses(events []domain.UnmatchedEvent) []UnmatchedLogResponse {
+	responses := make([]UnmatchedLogResponse, 0, len(events))
+	for _, e := range events {
+		responses = append(responses, UnmatchedLogResponse{AttendanceLog: e.Log, Reason: e.Reason})
+	}
+	return responses
+}
+
+// HoursBreakdownResponse は労働時間の区分ごとの合計（分）。
+// regular + overtime + holiday が total_minutes と等しく、late_night はそれらと重複して数える。
+type HoursBreakdownResponse struct {
+	RegularMinutes   int `json:"regular_minutes"`
+	OvertimeMinutes  int `json:"overtime_minutes"`
+	LateNightMinutes int `json:"late_night_minutes"`
+	HolidayMinutes   int `json:"holiday_minutes"`
+}
+
+func newHoursBreakdownResponse(b domain.HoursBreakdown) *HoursBreakdownResponse {
+	return &HoursBreakdownResponse{
+		RegularMinutes:   int(b.Regular.Minutes()),
+		OvertimeMinutes:  int(b.Overtime.Minutes()),
+		LateNightMinutes: int(b.LateNight.Minutes()),
+		HolidayMinutes:   int(b.Holiday.Minutes()),
+	}
+}
+
+// AttendanceRangeResponse は期間を指定した勤怠記録の取得結果。
+type AttendanceRangeResponse struct {
 	AttendanceLogs []domain.AttendanceLog `json:"attendance_logs,omitempty"`
-	FormattedData  string                 `json:"formatted_data,omitempty"`
+	From           string                 `json:"from,omitempty"`        // 取得した期間の開始 (RFC3339、職場のタイムゾーン)
+	To             string                 `json:"to,omitempty"`          // 取得した期間の終わり。この時刻は含まない
+	NextCursor     string                 `json:"next_cursor,omitempty"` // 続きを取得するときに cursor に指定する値。最後のページでは省略
+	Timezone       string                 `json:"timezone,omitempty"`
 	Message        string                 `json:"message"`
 	Success        bool                   `json:"success"`
 }
 
+// HoursSummaryResponse は給与計算期間の労働時間と見込み支給額の集計。
+type HoursSummaryResponse struct {
+	AttendanceLogs        []domain.AttendanceLog  `json:"attendance_logs,omitempty"`
+	TotalMinutes          int                     `json:"total_minutes"` // 休憩と法定休憩の自動控除を除いた実働時間（net）
+	GrossMinutes          int                     `json:"gross_minutes"` // 法定休憩の自動控除を行う前の労働時間（gross）
+	BreakMinutes          int                     `json:"break_minutes"`
+	StatutoryBreakMinutes int                     `json:"statutory_break_minutes"` // 法定休憩の不足分として自動で差し引いた時間
+	Breakdown             *HoursBreakdownResponse `json:"breakdown,omitempty"`
+	EstimatedGrossPay     *int64                  `json:"estimated_gross_pay,omitempty"`   // 見込み支給額（円）。時給が未設定の職場では省略
+	EstimatedPremiumPay   *int64                  `json:"estimated_premium_pay,omitempty"` // 見込み支給額のうち割増賃金（円）
+	UnpricedMinutes       int                     `json:"unpriced_minutes,omitempty"`      // 時給が設定されていない日の実働時間
+	UnmatchedLogs         []UnmatchedLogResponse  `json:"unmatched_logs,omitempty"`
+	FormattedData         string                  `json:"formatted_data,omitempty"`
+	Timezone              string                  `json:"timezone,omitempty"`
+	PeriodStart           string                  `json:"period_start,omitempty"` // 給与計算期間の初日 (YYYY-MM-DD)
+	PeriodEnd             string                  `json:"period_end,omitempty"`   // 給与計算期間の最終日 (YYYY-MM-DD)
+}
+
+// WorkplaceHoursResponse は職場ごとの月次の集計。
+type WorkplaceHoursResponse struct {
+	WorkplaceID   string `json:"workplace_id"`
+	WorkplaceName string `json:"workplace_name"`
+	HoursSummaryResponse
+}
+
+// MonthlyHoursResponse の集計はチャンネルに登録したすべての職場（workplace を指定した場合はその職場）の合計で、
+// 職場ごとの集計は Workplaces に入る。timezone と period_start / period_end は職場間で異なる場合は省略する。
+type MonthlyHoursResponse struct {
+	HoursSummaryResponse
+	Workplaces []WorkplaceHoursResponse `json:"workplaces,omitempty"`
+	Message    string                   `json:"message"`
+	Success    bool                     `json:"success"`
+}
+
+// newWorkplaceHoursResponse は月次レポートを職場ごとの集計にする。
+func newWorkplaceHoursResponse(report *monthlyReport) WorkplaceHoursResponse {
+	summary := HoursSummaryResponse{
+		AttendanceLogs: report.logs,
+		Timezone:       report.loc.String(),
+		PeriodStart:    report.from.Format("2006-01-02"),
+		PeriodEnd:      report.to.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	if len(report.logs) > 0 {
+		summary.TotalMinutes = int(report.timesheet.Total().Minutes())
+		summary.GrossMinutes = int(report.timesheet.WorkedTotal().Minutes())
+		summary.BreakMinutes = int(report.timesheet.BreakTotal().Minutes())
+		summary.StatutoryBreakMinutes = int(report.timesheet.StatutoryBreakTotal().Minutes())
+		summary.Breakdown = newHoursBreakdownResponse(report.breakdown)
+		summary.UnmatchedLogs = newUnmatchedLogResponses(report.timesheet.Unmatched)
+		summary.FormattedData = report.format()
+		if report.pay != nil {
+			summary.EstimatedGrossPay = &report.pay.GrossPay
+			summary.EstimatedPremiumPay = &report.pay.PremiumPay
+			summary.UnpricedMinutes = int(report.pay.UnpricedDuration.Minutes())
+		}
+	}
+
+	return WorkplaceHoursResponse{
+		WorkplaceID:          report.binding.WorkplaceID,
+		WorkplaceName:        report.binding.Workplace,
+		HoursSummaryResponse: summary,
+	}
+}
+
+// add は別の職場の集計を合算する。
+func (s *HoursSummaryResponse) add(o HoursSummaryResponse) {
+	s.AttendanceLogs = append(s.AttendanceLogs, o.AttendanceLogs...)
+	s.TotalMinutes += o.TotalMinutes
+	s.GrossMinutes += o.GrossMinutes
+	s.BreakMinutes += o.BreakMinutes
+	s.StatutoryBreakMinutes += o.StatutoryBreakMinutes
+	if o.Breakdown != nil {
+		if s.Breakdown == nil {
+			s.Breakdown = &HoursBreakdownResponse{}
+		}
+		s.Breakdown.RegularMinutes += o.Breakdown.RegularMinutes
+		s.Breakdown.OvertimeMinutes += o.Breakdown.OvertimeMinutes
+		s.Breakdown.LateNightMinutes += o.Breakdown.LateNightMinutes
+		s.Breakdown.HolidayMinutes += o.Breakdown.HolidayMinutes
+	}
+	s.EstimatedGrossPay = addYen(s.EstimatedGrossPay, o.EstimatedGrossPay)
+	s.EstimatedPremiumPay = addYen(s.EstimatedPremiumPay, o.EstimatedPremiumPay)
+	s.UnpricedMinutes += o.UnpricedMinutes
+	s.UnmatchedLogs = append(s.UnmatchedLogs, o.UnmatchedLogs...)
+	if o.FormattedData != "" {
+		if s.FormattedData != "" {
+			s.FormattedData += "\n"
+		}
+		s.FormattedData += o.FormattedData
+	}
+}
+
+// addYen は金額を合算する。どちらも nil（時給が未設定）の場合は nil を返す。
+func addYen(a, b *int64) *int64 {
+	if b == nil {
+		return a
+	}
+	sum := *b
+	if a != nil {
+		sum += *a
+	}
+	return &sum
+}
+
 func (h *Handler) CheckIn(c echo.Context) error {
 	var req CheckInRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, AttendanceResponse{
-			Message: "Invalid request format",
-			Success: false,
-		})
+		return validationError("Invalid request format")
+	}
+
+	session, ok := authorizeSession(c, req.TeamID, req.UserID)
+	if !ok {
+		return forbiddenError("他のユーザーの勤怠は操作できません")
 	}
 
-	attendanceLog, err := h.usecase.AddAttendanceLogStart(c.Request().Context(), req.TeamID, req.ChannelID, req.UserID, "start")
+	attendanceLog, err := h.usecase.AddAttendanceLogStart(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace, domain.ActionStart)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, AttendanceResponse{
-			Message: "Failed to check in: " + err.Error(),
-			Success: false,
-		})
+		return fmt.Errorf("Failed to check in: %w", err)
 	}
 
 	return c.JSON(http.StatusOK, AttendanceResponse{
 		AttendanceLog: attendanceLog,
-		Message:       attendanceLog.WorkplaceID + ": 出勤",
+		Message:       attendanceLog.WorkplaceName + ": 出勤",
 		Success:       true,
 	})
 }
@@ -99,23 +312,68 @@ func (h *Handler) CheckIn(c echo.Context) error {
 func (h *Handler) CheckOut(c echo.Context) error {
 	var req CheckOutRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, AttendanceResponse{
-			Message: "Invalid request format",
-			Success: false,
-		})
+		return validationError("Invalid request format")
+	}
+
+	session, ok := authorizeSession(c, req.TeamID, req.UserID)
+	if !ok {
+		return forbiddenError("他のユーザーの勤怠は操作できません")
 	}
 
-	attendanceLog, err := h.usecase.AddAttendanceLogEnd(c.Request().Context(), req.TeamID, req.ChannelID, req.UserID, "end")
+	attendanceLog, err := h.usecase.AddAttendanceLogEnd(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace, domain.ActionEnd)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, AttendanceResponse{
-			Message: "Failed to check out: " + err.Error(),
-			Success: false,
-		})
+		return fmt.Errorf("Failed to check out: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, AttendanceResponse{
+		AttendanceLog: attendanceLog,
+		Message:       attendanceLog.WorkplaceName + ": 退勤",
+		Success:       true,
+	})
+}
+
+func (h *Handler) BreakStart(c echo.Context) error {
+	var req BreakRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+
+	session, ok := authorizeSession(c, req.TeamID, req.UserID)
+	if !ok {
+		return forbiddenError("他のユーザーの勤怠は操作できません")
+	}
+
+	attendanceLog, err := h.usecase.AddAttendanceLogBreakStart(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace, domain.ActionBreakStart)
+	if err != nil {
+		return fmt.Errorf("Failed to start break: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, AttendanceResponse{
+		AttendanceLog: attendanceLog,
+		Message:       attendanceLog.WorkplaceName + ": 休憩開始",
+		Success:       true,
+	})
+}
+
+func (h *Handler) BreakEnd(c echo.Context) error {
+	var req BreakRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+
+	session, ok := authorizeSession(c, req.TeamID, req.UserID)
+	if !ok {
+		return forbiddenError("他のユーザーの勤怠は操作できません")
+	}
+
+	attendanceLog, err := h.usecase.AddAttendanceLogBreakEnd(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace, domain.ActionBreakEnd)
+	if err != nil {
+		return fmt.Errorf("Failed to end break: %w", err)
 	}
 
 	return c.JSON(http.StatusOK, AttendanceResponse{
 		AttendanceLog: attendanceLog,
-		Message:       attendanceLog.WorkplaceID + ": 退勤",
+		Message:       attendanceLog.WorkplaceName + ": 休憩終了",
 		Success:       true,
 	})
 }
@@ -123,18 +381,17 @@ func (h *Handler) CheckOut(c echo.Context) error {
 func (h *Handler) SubscribeWorkplace(c echo.Context) error {
 	var req SubscribeWorkplaceRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, WorkplaceResponse{
-			Message: "Invalid request format",
-			Success: false,
-		})
+		return validationError("Invalid request format")
 	}
 
-	workplaceBinding, err := h.usecase.SubscribeWorkplace(c.Request().Context(), req.TeamID, req.ChannelID, req.UserID, req.WorkplaceName)
+	session, ok := authorizeSession(c, req.TeamID, req.UserID)
+	if !ok {
+		return forbiddenError("他のユーザーの勤怠は操作できません")
+	}
+
+	workplaceBinding, err := h.usecase.SubscribeWorkplace(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.WorkplaceName, req.Timezone)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, WorkplaceResponse{
-			Message: "Failed to subscribe workplace: " + err.Error(),
-			Success: false,
-		})
+		return fmt.Errorf("Failed to subscribe workplace: %w", err)
 	}
 
 	return c.JSON(http.StatusOK, WorkplaceResponse{
@@ -144,85 +401,292 @@ func (h *Handler) SubscribeWorkplace(c echo.Context) error {
 	})
 }
 
+// UnsubscribeWorkplace は職場の登録を解除する。勤怠記録は削除しない。
+func (h *Handler) UnsubscribeWorkplace(c echo.Context) error {
+	var req UnsubscribeWorkplaceRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+	if req.ChannelID == "" {
+		return validationError("channel_id is required")
+	}
+
+	session := sessionFromContext(c)
+	workplaceBinding, err := h.usecase.UnsubscribeWorkplace(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace)
+	if err != nil {
+		return fmt.Errorf("Failed to unsubscribe workplace: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, WorkplaceResponse{
+		WorkplaceBinding: workplaceBinding,
+		Message:          "職場の登録を解除しました: " + workplaceBinding.Workplace,
+		Success:          true,
+	})
+}
+
+func (h *Handler) RenameWorkplace(c echo.Context) error {
+	var req RenameWorkplaceRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+	if req.ChannelID == "" || req.NewName == "" {
+		return validationError("channel_id and new_name are required")
+	}
+
+	session := sessionFromContext(c)
+	workplaceBinding, err := h.usecase.RenameWorkplace(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace, req.NewName)
+	if err != nil {
+		return fmt.Errorf("Failed to rename workplace: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, WorkplaceResponse{
+		WorkplaceBinding: workplaceBinding,
+		Message:          "職場名を変更しました: " + workplaceBinding.Workplace,
+		Success:          true,
+	})
+}
+
+// GetWorkplaces はチャンネルに登録中の職場と、登録を解除した職場を返す。
+func (h *Handler) GetWorkplaces(c echo.Context) error {
+	channelID := c.QueryParam("channel_id")
+	if channelID == "" {
+		return validationError("channel_id is required")
+	}
+
+	session := sessionFromContext(c)
+	workplaces, err := h.usecase.GetWorkplaceBindings(c.Request().Context(), session.TeamID, channelID, session.UserID)
+	if err != nil && !errors.Is(err, domain.ErrNotSubscribed) {
+		return fmt.Errorf("Failed to get workplaces: %w", err)
+	}
+	archived, err := h.usecase.GetArchivedWorkplaceBindings(c.Request().Context(), session.TeamID, channelID, session.UserID)
+	if err != nil {
+		return fmt.Errorf("Failed to get workplaces: %w", err)
+	}
+	if workplaces == nil {
+		workplaces = []domain.WorkplaceBindings{}
+	}
+
+	return c.JSON(http.StatusOK, WorkplacesResponse{
+		Workplaces:         workplaces,
+		ArchivedWorkplaces: archived,
+		Message:            "Successfully retrieved workplaces",
+		Success:            true,
+	})
+}
+
+func (h *Handler) UpdateWorkplaceSettings(c echo.Context) error {
+	var req UpdateWorkplaceSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+	if req.ChannelID == "" || len(req.Settings) == 0 {
+		return validationError("channel_id and settings are required")
+	}
+
+	session := sessionFromContext(c)
+	workplaceBinding, err := h.usecase.UpdateWorkplaceSettings(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace, req.Settings)
+	if err != nil {
+		return fmt.Errorf("Failed to update workplace settings: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, WorkplaceResponse{
+		WorkplaceBinding: workplaceBinding,
+		Message:          "職場設定を更新しました: " + workplaceBinding.Workplace,
+		Success:          true,
+	})
+}
+
+func (h *Handler) SetHourlyWage(c echo.Context) error {
+	var req SetHourlyWageRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+	if req.ChannelID == "" {
+		return validationError("channel_id is required")
+	}
+
+	session := sessionFromContext(c)
+	workplaceBinding, err := h.usecase.SetHourlyWage(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.Workplace, req.HourlyWage, req.EffectiveFrom)
+	if err != nil {
+		return fmt.Errorf("Failed to set hourly wage: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, WorkplaceResponse{
+		WorkplaceBinding: workplaceBinding,
+		Message:          "時給を登録しました: " + workplaceBinding.Workplace,
+		Success:          true,
+	})
+}
+
 func (h *Handler) GetMonthlyHours(c echo.Context) error {
 	teamID := c.QueryParam("team_id")
 	channelID := c.QueryParam("channel_id")
 	userID := c.QueryParam("user_id")
+	workplace := c.QueryParam("workplace")
 	yearMonth := c.QueryParam("year_month")
 
-	if teamID == "" || channelID == "" || userID == "" {
-		return c.JSON(http.StatusBadRequest, MonthlyHoursResponse{
-			Message: "team_id, channel_id, and user_id are required",
-			Success: false,
-		})
+	if channelID == "" {
+		return validationError("channel_id is required")
 	}
 
-	if yearMonth == "" {
-		jst, _ := time.LoadLocation("Asia/Tokyo")
-		now := time.Now().In(jst)
-		yearMonth = now.Format("200601")
+	// team_id, user_id は省略可能。指定する場合はセッションの本人と一致している必要がある。
+	session, ok := authorizeSession(c, teamID, userID)
+	if !ok {
+		return forbiddenError("他のユーザーの勤怠は参照できません")
 	}
 
-	if len(yearMonth) != 6 {
-		return c.JSON(http.StatusBadRequest, MonthlyHoursResponse{
-			Message: "年月の形式が不正です。",
-			Success: false,
-		})
+	if yearMonth != "" {
+		if _, _, ok := splitYearMonth(yearMonth); !ok {
+			return validationError("年月の形式が不正です。")
+		}
+	}
+
+	// workplace を省略した場合はチャンネルに登録したすべての職場を集計する
+	var bindings []domain.WorkplaceBindings
+	if workplace != "" {
+		binding, err := h.usecase.GetWorkplaceBinding(c.Request().Context(), session.TeamID, channelID, session.UserID, workplace)
+		if err != nil {
+			return fmt.Errorf("Failed to get attendance log: %w", err)
+		}
+		bindings = []domain.WorkplaceBindings{*binding}
+	} else {
+		var err error
+		bindings, err = h.usecase.GetWorkplaceBindings(c.Request().Context(), session.TeamID, channelID, session.UserID)
+		if err != nil {
+			return fmt.Errorf("Failed to get attendance log: %w", err)
+		}
+	}
+
+	response := MonthlyHoursResponse{
+		Workplaces: make([]WorkplaceHoursResponse, 0, len(bindings)),
+		Message:    "Successfully retrieved attendance logs",
+		Success:    true,
+	}
+	response.AttendanceLogs = []domain.AttendanceLog{}
+	for i := range bindings {
+		report, err := h.buildMonthlyReport(c, session.TeamID, channelID, session.UserID, &bindings[i], yearMonth)
+		if err != nil {
+			return fmt.Errorf("Failed to get attendance log: %w", err)
+		}
+		workplaceHours := newWorkplaceHoursResponse(report)
+		response.Workplaces = append(response.Workplaces, workplaceHours)
+		response.add(workplaceHours.HoursSummaryResponse)
+	}
+
+	// タイムゾーンと期間はすべての職場で同じ場合のみ示す
+	first := response.Workplaces[0]
+	response.Timezone, response.PeriodStart, response.PeriodEnd = first.Timezone, first.PeriodStart, first.PeriodEnd
+	for _, w := range response.Workplaces[1:] {
+		if w.Timezone != first.Timezone {
+			response.Timezone = ""
+		}
+		if w.PeriodStart != first.PeriodStart || w.PeriodEnd != first.PeriodEnd {
+			response.PeriodStart, response.PeriodEnd = "", ""
+		}
+	}
+
+	if len(response.AttendanceLogs) == 0 {
+		response.Message = "出勤記録がありません。"
 	}
 
-	year := yearMonth[:4]
-	month := yearMonth[4:]
-	attendanceLogs, err := h.usecase.GetAttendanceLogListByUserAndMonth(c.Request().Context(), teamID, channelID, userID, year, month)
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetAttendanceLogs は from から to までの勤怠記録を返す。
+// from, to は RFC3339 形式の時刻か、職場のタイムゾーンでの日付 (YYYY-MM-DD) で指定する。日付で指定した to はその日を含む。
+// 1回に最大 limit 件を返し、続きがある場合は next_cursor を cursor に指定して取得する。
+func (h *Handler) GetAttendanceLogs(c echo.Context) error {
+	channelID := c.QueryParam("channel_id")
+	if channelID == "" {
+		return validationError("channel_id is required")
+	}
+
+	workplace := c.QueryParam("workplace")
+
+	session := sessionFromContext(c)
+	_, loc, err := h.workplaceLocation(c, session.TeamID, channelID, session.UserID, workplace)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, MonthlyHoursResponse{
-			Message: "Failed to get attendance log: " + err.Error(),
-			Success: false,
-		})
+		return fmt.Errorf("Failed to get attendance log: %w", err)
 	}
 
-	if len(attendanceLogs) == 0 {
-		return c.JSON(http.StatusOK, MonthlyHoursResponse{
-			AttendanceLogs: []domain.AttendanceLog{},
-			Message:        "出勤記録がありません。",
-			Success:        true,
-		})
+	from, err := parseRangeBound(c.QueryParam("from"), loc, false)
+	if err != nil {
+		return validationError("from の形式が不正です: " + err.Error())
+	}
+	to, err := parseRangeBound(c.QueryParam("to"), loc, true)
+	if err != nil {
+		return validationError("to の形式が不正です: " + err.Error())
 	}
 
-	workplaceName := attendanceLogs[0].WorkplaceID
-	formattedData := FormatAttendance(attendanceLogs, workplaceName)
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return validationError("limit は正の整数で指定してください")
+		}
+	}
+
+	page, err := h.usecase.GetAttendanceLogPageByUserAndRange(c.Request().Context(), session.TeamID, channelID, session.UserID, workplace, from, to, c.QueryParam("cursor"), limit)
+	if err != nil {
+		return fmt.Errorf("Failed to get attendance log: %w", err)
+	}
 
-	return c.JSON(http.StatusOK, MonthlyHoursResponse{
-		AttendanceLogs: attendanceLogs,
-		FormattedData:  formattedData,
+	return c.JSON(http.StatusOK, AttendanceRangeResponse{
+		AttendanceLogs: page.Logs,
+		From:           from.In(loc).Format(time.RFC3339),
+		To:             to.In(loc).Format(time.RFC3339),
+		NextCursor:     page.NextCursor,
+		Timezone:       loc.String(),
 		Message:        "Successfully retrieved attendance logs",
 		Success:        true,
 	})
 }
 
+// parseRangeBound は期間の端を解釈する。日付だけの指定は loc でのその日の0時とし、end の場合は翌日の0時にする。
+func parseRangeBound(value string, loc *time.Location, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("required")
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC3339 nor YYYY-MM-DD", value)
+	}
+	if end {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date, nil
+}
+
 func (h *Handler) EditAttendance(c echo.Context) error {
 	var req EditAttendanceRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, AttendanceResponse{
-			Message: "Invalid request format",
-			Success: false,
-		})
+		return validationError("Invalid request format")
 	}
 
-	jst, _ := time.LoadLocation("Asia/Tokyo")
-	newTime, err := time.ParseInLocation("2006-01-02 15:04", req.NewDateTime, jst)
+	session := sessionFromContext(c)
+	loc, err := h.attendanceLogLocation(c, session.TeamID, req.ChannelID, session.UserID, req.ID)
+	if errors.Is(err, domain.ErrForbidden) {
+		return forbiddenError("この勤怠記録を更新する権限がありません")
+	}
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, AttendanceResponse{
-			Message: "時刻の形式が不正です。形式: YYYY-MM-DD HH:MM",
-			Success: false,
-		})
+		return fmt.Errorf("勤怠記録の更新に失敗しました: %w", err)
 	}
 
-	updatedLog, err := h.usecase.UpdateAttendanceLog(c.Request().Context(), req.ID, newTime)
+	// 入力された時刻は記録の職場のタイムゾーンとして解釈する
+	newTime, err := time.ParseInLocation("2006-01-02 15:04", req.NewDateTime, loc)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, AttendanceResponse{
-			Message: "勤怠記録の更新に失敗しました: " + err.Error(),
-			Success: false,
-		})
+		return validationError("時刻の形式が不正です。形式: YYYY-MM-DD HH:MM")
+	}
+
+	updatedLog, err := h.usecase.UpdateAttendanceLog(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, req.ID, newTime)
+	if errors.Is(err, domain.ErrForbidden) {
+		return forbiddenError("この勤怠記録を更新する権限がありません")
+	}
+	if err != nil {
+		return fmt.Errorf("勤怠記録の更新に失敗しました: %w", err)
 	}
 
 	return c.JSON(http.StatusOK, AttendanceResponse{
@@ -235,18 +699,21 @@ func (h *Handler) EditAttendance(c echo.Context) error {
 func (h *Handler) DeleteAttendance(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
-		return c.JSON(http.StatusBadRequest, AttendanceResponse{
-			Message: "ID is required",
-			Success: false,
-		})
+		return validationError("ID is required")
+	}
+
+	channelID := c.QueryParam("channel_id")
+	if channelID == "" {
+		return validationError("channel_id is required")
 	}
 
-	err := h.usecase.DeleteAttendanceLog(c.Request().Context(), id)
+	session := sessionFromContext(c)
+	err := h.usecase.DeleteAttendanceLog(c.Request().Context(), session.TeamID, channelID, session.UserID, id)
+	if errors.Is(err, domain.ErrForbidden) {
+		return forbiddenError("この勤怠記録を削除する権限がありません")
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, AttendanceResponse{
-			Message: "勤怠記録の削除に失敗しました: " + err.Error(),
-			Success: false,
-		})
+		return fmt.Errorf("勤怠記録の削除に失敗しました: %w", err)
 	}
 
 	return c.JSON(http.StatusOK, AttendanceResponse{
@@ -280,10 +747,10 @@ type SlackUserResponse struct {
 }
 
 type SlackChannel struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	IsGroup bool   `json:"is_group"`
-	IsMember bool  `json:"is_member"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsGroup  bool   `json:"is_group"`
+	IsMember bool   `json:"is_member"`
 }
 
 type SlackChannelsResponse struct {
@@ -297,9 +764,39 @@ type SlackChannelsResponse struct {
 
 func (h *Handler) SlackOAuthCallback(c echo.Context) error {
 	code := c.QueryParam("code")
-	// state := c.QueryParam("state")
+	state := c.QueryParam("state")
 	error := c.QueryParam("error")
 
+	if len(h.sessionSecret) == 0 {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   "config_error",
+			"message": "SESSION_SECRET が設定されていません",
+		})
+	}
+
+	// 検証の成否にかかわらず Cookie を削除する
+	stateCookie, _ := c.Cookie(oauthStateCookieName)
+	c.SetCookie(newOAuthStateCookie("", -1))
+
+	var stateCookieValue string
+	if stateCookie != nil {
+		stateCookieValue = stateCookie.Value
+	}
+	if err := verifyOAuthState(h.sessionSecret, stateCookieValue, state, h.clock.Now()); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "invalid_state",
+			"message": "認証リクエストの検証に失敗しました。再度ログインしてください: " + err.Error(),
+		})
+	}
+	// Cookie と state の組を盗まれても再利用できないよう、サーバー側に保管した state を削除する。
+	// 削除できるのは1回だけなので、使用済みの state は拒否される
+	if err := h.usecase.ConsumeOAuthState(c.Request().Context(), state); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "invalid_state",
+			"message": "認証リクエストの検証に失敗しました。再度ログインしてください: " + err.Error(),
+		})
+	}
+
 	if error != "" {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{
 			"error":   error,
@@ -331,7 +828,7 @@ func (h *Handler) SlackOAuthCallback(c echo.Context) error {
 	data.Set("code", code)
 	data.Set("redirect_uri", redirectURI)
 
-	resp, err := http.PostForm("https://slack.com/api/oauth.v2.access", data)
+	resp, err := h.httpClient.PostForm(h.slackBaseURL+"/api/oauth.v2.access", data)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"error":   "oauth_request_failed",
@@ -363,6 +860,29 @@ func (h *Handler) SlackOAuthCallback(c echo.Context) error {
 		})
 	}
 
+	// Bot Token / User Token はブラウザに返さず、暗号化してサーバー側に保管する
+	if err := h.usecase.SaveSlackToken(c.Request().Context(), oauthResp.Team.ID, oauthResp.AuthedUser.ID, oauthResp.AccessToken, oauthResp.AuthedUser.AccessToken, oauthResp.Scope); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   "token_store_failed",
+			"message": "トークンの保存に失敗しました: " + err.Error(),
+		})
+	}
+
+	now := h.clock.Now()
+	sessionToken, err := issueSessionToken(h.sessionSecret, Session{
+		TeamID:    oauthResp.Team.ID,
+		UserID:    oauthResp.AuthedUser.ID,
+		TeamName:  oauthResp.Team.Name,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(sessionTTL).Unix(),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   "session_issue_failed",
+			"message": "セッションの発行に失敗しました: " + err.Error(),
+		})
+	}
+
 	// OAuth v2 では追加の users.info 呼び出しは不要
 	// 必要に応じて後でユーザー名を取得できるが、基本情報は OAuth レスポンスに含まれる
 	session := map[string]interface{}{
@@ -371,8 +891,8 @@ func (h *Handler) SlackOAuthCallback(c echo.Context) error {
 		"team_name":     oauthResp.Team.Name,
 		"user_name":     oauthResp.AuthedUser.ID, // 一時的にユーザーIDを使用
 		"scope":         oauthResp.Scope,
-		"access_token":  oauthResp.AccessToken, // Bot Token (チャンネル情報取得用)
-		"user_token":    oauthResp.AuthedUser.AccessToken, // User Token
+		"session_token": sessionToken, // REST API 呼び出し時に Authorization: Bearer で送る
+		"expires_at":    now.Add(sessionTTL).Unix(),
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -386,7 +906,7 @@ func (h *Handler) SlackOAuthLogin(c echo.Context) error {
 	clientID := os.Getenv("SLACK_CLIENT_ID")
 	redirectURI := os.Getenv("SLACK_REDIRECT_URI")
 
-	if clientID == "" || redirectURI == "" {
+	if clientID == "" || redirectURI == "" || len(h.sessionSecret) == 0 {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"error":   "config_error",
 			"message": "Slack OAuth設定が不足しています",
@@ -394,14 +914,30 @@ func (h *Handler) SlackOAuthLogin(c echo.Context) error {
 	}
 
 	scopes := []string{"channels:read", "groups:read", "channels:history", "groups:history"}
-	state := fmt.Sprintf("%d", time.Now().Unix())
+	state, err := newOAuthState()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   "state_generation_failed",
+			"message": "認証リクエストの生成に失敗しました: " + err.Error(),
+		})
+	}
+	// state は一度だけ使えるようサーバー側に保管し、このブラウザに紐付けるため署名付きの Cookie にも保存する
+	expiresAt := h.clock.Now().Add(oauthStateTTL)
+	if err := h.usecase.SaveOAuthState(c.Request().Context(), state, expiresAt); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   "state_generation_failed",
+			"message": "認証リクエストの生成に失敗しました: " + err.Error(),
+		})
+	}
+	c.SetCookie(newOAuthStateCookie(encodeOAuthStateCookie(h.sessionSecret, state, expiresAt.Unix()), int(oauthStateTTL.Seconds())))
 
 	authURL := fmt.Sprintf(
-		"https://slack.com/oauth/v2/authorize?client_id=%s&scope=%s&redirect_uri=%s&state=%s",
+		"%s/oauth/v2/authorize?client_id=%s&scope=%s&redirect_uri=%s&state=%s",
+		h.slackBaseURL,
 		clientID,
 		url.QueryEscape(strings.Join(scopes, ",")),
 		url.QueryEscape(redirectURI),
-		state,
+		url.QueryEscape(state),
 	)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -411,16 +947,19 @@ func (h *Handler) SlackOAuthLogin(c echo.Context) error {
 }
 
 func (h *Handler) GetSlackChannels(c echo.Context) error {
-	accessToken := c.QueryParam("access_token")
-	if accessToken == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error":   "missing_access_token",
-			"message": "アクセストークンが必要です",
+	// Bot Token はセッションのユーザーに紐付けてサーバー側で保管しているものを使う
+	session := sessionFromContext(c)
+	slackToken, err := h.usecase.GetSlackToken(c.Request().Context(), session.TeamID, session.UserID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"error":   "slack_token_not_found",
+			"message": "Slackのトークンが見つかりません。再度ログインしてください: " + err.Error(),
 		})
 	}
+	accessToken := slackToken.BotToken
 
 	// パブリックチャンネルを取得
-	channelsReq, err := http.NewRequest("GET", "https://slack.com/api/conversations.list?types=public_channel,private_channel", nil)
+	channelsReq, err := http.NewRequest("GET", h.slackBaseURL+"/api/conversations.list?types=public_channel,private_channel", nil)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"error":   "channels_request_creation_failed",
@@ -428,9 +967,8 @@ func (h *Handler) GetSlackChannels(c echo.Context) error {
 		})
 	}
 	channelsReq.Header.Set("Authorization", "Bearer "+accessToken)
-	
-	client := &http.Client{}
-	channelsResp, err := client.Do(channelsReq)
+
+	channelsResp, err := h.httpClient.Do(channelsReq)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"error":   "channels_request_failed",
@@ -475,19 +1013,3 @@ func (h *Handler) GetSlackChannels(c echo.Context) error {
 		"message":  fmt.Sprintf("チャンネル一覧を取得しました (%d件)", len(channels)),
 	})
 }
-
-// 今は使わない
-// func (h *Handler) AttendanceLogListByUserAndMonth(c echo.Context) error {
-// 	teamId := c.Param("team_id")
-// 	channelId := c.Param("channel_id")
-// 	userId := ""
-// 	year := c.Param("year")
-// 	month := c.Param("month")
-
-// 	attendanceLogs, err := h.usecase.GetAttendanceLogListByUserAndMonth(c.Request().Context(), teamId, channelId, userId, year, month)
-// 	if err != nil {
-// 		return c.JSON(http.StatusInternalServerError, err.Error())
-// 	}
-
-// 	return c.JSON(http.StatusOK, attendanceLogs)
-// }
@@ -0,0 +1,167 @@
+package presentation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+const (
+	// idempotencyKeyHeader は REST API のクライアントがリクエストを一意に識別するために付けるヘッダー
+	idempotencyKeyHeader = "Idempotency-Key"
+	// slackRetryNumHeader は Slack が応答の遅れたリクエストを再送するときに付けるヘッダー
+	slackRetryNumHeader = "X-Slack-Retry-Num"
+	// idempotentReplayedHeader は保存していた応答を返したことを示すヘッダー
+	idempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength は Idempotency-Key の最大長
+	maxIdempotencyKeyLength = 255
+	// maxAPIRequestBodySize は Idempotency-Key の判定のために読み込む REST API のリクエストボディの上限
+	maxAPIRequestBodySize = 64 << 10
+)
+
+// errRequestBodyTooLarge はリクエストボディが上限を超えていることを表す。
+var errRequestBodyTooLarge = errors.New("request body too large")
+
+// SlackIdempotency は Slack からの同じリクエストに、最初の応答をそのまま返すミドルウェア。
+// Lambda のコールドスタートで3秒以内に応答できないと Slack は X-Slack-Retry-Num を付けて同じ内容を再送するため、
+// 署名済みのボディのハッシュをキーにする。VerifySlackSignature の後に使うこと。
+func (h *Handler) SlackIdempotency(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := readAndRestoreBody(c, maxSlackRequestBodySize)
+		if errors.Is(err, errRequestBodyTooLarge) {
+			return slackRequestTooLarge(c)
+		}
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "request_read_failed",
+				"message": "リクエストの読み込みに失敗しました: " + err.Error(),
+			})
+		}
+
+		hash := sha256Hex(body)
+		return h.idempotent(c, next, "slack#"+hash, hash, func(err error) error {
+			if retry := c.Request().Header.Get(slackRetryNumHeader); retry != "" {
+				log.Printf("Slack の再送を受け取りました (retry=%s, reason=%s): %v", retry, c.Request().Header.Get("X-Slack-Retry-Reason"), err)
+			}
+			// 最初のリクエストを処理中の場合は、再送に空の応答を返して二重に処理しない
+			return c.NoContent(http.StatusOK)
+		})
+	}
+}
+
+// IdempotencyKey は Idempotency-Key ヘッダーが付いた REST API のリクエストに、最初の応答をそのまま返すミドルウェア。
+// キーはユーザーごとに区別する。ヘッダーが無いリクエストと GET はそのまま処理する。RequireSession の後に使うこと。
+func (h *Handler) IdempotencyKey(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(idempotencyKeyHeader)
+		if key == "" || c.Request().Method == http.MethodGet {
+			return next(c)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return validationError("Idempotency-Key が長すぎます")
+		}
+
+		body, err := readAndRestoreBody(c, maxAPIRequestBodySize)
+		if errors.Is(err, errRequestBodyTooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "リクエストボディが大きすぎます")
+		}
+		if err != nil {
+			return validationError("Invalid request format")
+		}
+
+		session := sessionFromContext(c)
+		req := c.Request()
+		hash := sha256Hex([]byte(req.Method + " " + req.URL.RequestURI() + "\n" + string(body)))
+		return h.idempotent(c, next, "api#"+session.TeamID+"#"+session.UserID+"#"+key, hash, func(err error) error {
+			if errors.Is(err, domain.ErrIdempotencyKeyMismatch) {
+				return domain.NewError(err, "Idempotency-Key が別のリクエストで使われています")
+			}
+			return domain.NewError(err, "同じ Idempotency-Key のリクエストを処理中です")
+		})
+	}
+}
+
+// idempotent は key のリクエストを一度だけ処理し、応答を保存する。保存した応答があればそれを返す。
+// 処理中や内容の異なるリクエストの場合は onConflict の応答を返す。
+// 5xx の応答や同時更新の競合の場合は保存せず、同じキーで再試行できるようにする。
+// 記録の読み書きに失敗した場合は打刻できなくなるのを避けるため、記録せずにそのまま処理する。
+func (h *Handler) idempotent(c echo.Context, next echo.HandlerFunc, key, requestHash string, onConflict func(error) error) error {
+	ctx := c.Request().Context()
+	record, err := h.usecase.BeginIdempotentRequest(ctx, key, requestHash)
+	switch {
+	case errors.Is(err, domain.ErrIdempotencyInProgress), errors.Is(err, domain.ErrIdempotencyKeyMismatch):
+		return onConflict(err)
+	case err != nil:
+		log.Printf("Error: idempotency record %s: %v", key, err)
+		return next(c)
+	case record != nil:
+		c.Response().Header().Set(idempotentReplayedHeader, "true")
+		return c.Blob(record.StatusCode, record.ContentType, record.Body)
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+	c.Response().Writer = recorder
+	handlerErr := next(c)
+	if handlerErr != nil {
+		// 4xx の応答も同じキーの再送に返せるよう、エラーの応答をここで書き込んで控える
+		c.Error(handlerErr)
+	}
+	c.Response().Writer = recorder.ResponseWriter
+
+	status := c.Response().Status
+	if status >= http.StatusInternalServerError || errors.Is(handlerErr, domain.ErrConflict) {
+		if err := h.usecase.AbortIdempotentRequest(ctx, key); err != nil {
+			log.Printf("Error: idempotency record %s: %v", key, err)
+		}
+		return nil
+	}
+
+	if err := h.usecase.CompleteIdempotentRequest(ctx, key, requestHash, status, c.Response().Header().Get(echo.HeaderContentType), recorder.body.Bytes()); err != nil {
+		log.Printf("Error: idempotency record %s: %v", key, err)
+	}
+
+	return nil
+}
+
+// responseRecorder はクライアントに書き込む応答のボディを保存用に控える。
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// readAndRestoreBody はリクエストボディを読み込み、後続のハンドラーが再度読めるように戻す。
+// ボディが limit バイトを超える場合は切り詰めずに errRequestBodyTooLarge を返す。
+func readAndRestoreBody(c echo.Context, limit int64) ([]byte, error) {
+	req := c.Request()
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, errRequestBodyTooLarge
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
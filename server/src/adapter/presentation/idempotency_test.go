@@ -0,0 +1,69 @@
+package presentation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuorei/attendance/src/adapter/infrastructure/memory"
+	"github.com/yuorei/attendance/src/driver/clock"
+	"github.com/yuorei/attendance/src/usecase"
+)
+
+func TestIdempotencyKeyBodyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int // 0 の場合は後続のハンドラーまで届く
+	}{
+		{name: "body at the limit", size: maxAPIRequestBodySize},
+		{name: "body over the limit", size: maxAPIRequestBodySize + 1, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixedClock := clock.NewFixedClock(time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+			repo := memory.NewMemory()
+			h := NewHandler(usecase.NewRepository(repo, repo, repo, repo, fixedClock), fixedClock)
+
+			body := strings.Repeat("a", tt.size)
+			var seen string
+			next := func(c echo.Context) error {
+				read, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return err
+				}
+				seen = string(read)
+				return c.NoContent(http.StatusCreated)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/attendance/start", strings.NewReader(body))
+			req.Header.Set(idempotencyKeyHeader, "key-1")
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.Set(sessionContextKey, &Session{TeamID: "T1", UserID: "U1"})
+			err := h.IdempotencyKey(next)(c)
+
+			if tt.wantStatus != 0 {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) || httpErr.Code != tt.wantStatus {
+					t.Fatalf("IdempotencyKey() error = %v, want status %d", err, tt.wantStatus)
+				}
+				if seen != "" {
+					t.Error("next handler must not be called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IdempotencyKey() error = %v", err)
+			}
+			if seen != body {
+				t.Errorf("handler read %d bytes, want the whole %d byte body", len(seen), len(body))
+			}
+		})
+	}
+}
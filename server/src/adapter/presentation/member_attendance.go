@@ -0,0 +1,213 @@
+package presentation
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// マネージャーがチームの職場のメンバーの勤怠記録を参照・修正する API。
+// 時刻は職場のタイムゾーンで解釈し、追加・編集・削除は操作したユーザーを監査ログに残す。
+
+// Action は start / end / break_start / break_end。DateTime は職場のタイムゾーンでの時刻 (YYYY-MM-DD HH:MM)。
+type AddMemberAttendanceRequest struct {
+	UserID   string `json:"user_id" validate:"required"`
+	Action   string `json:"action" validate:"required"`
+	DateTime string `json:"datetime" validate:"required"`
+}
+
+type EditMemberAttendanceRequest struct {
+	NewDateTime string `json:"new_datetime" validate:"required"`
+}
+
+type SetWorkplaceRoleRequest struct {
+	Role string `json:"role" validate:"required"` // member / manager / admin
+}
+
+type AttendanceAuditsResponse struct {
+	Audits   []domain.AttendanceAudit `json:"audits"`
+	From     string                   `json:"from,omitempty"`
+	To       string                   `json:"to,omitempty"`
+	Timezone string                   `json:"timezone,omitempty"`
+	Message  string                   `json:"message"`
+	Success  bool                     `json:"success"`
+}
+
+// GetMemberAttendanceLogs は職場のメンバーの勤怠記録を返す。user_id を省略した場合はすべてのメンバーの記録を返す。
+// from, to は GetAttendanceLogs と同じく RFC3339 形式の時刻か、職場のタイムゾーンでの日付で指定する。
+func (h *Handler) GetMemberAttendanceLogs(c echo.Context) error {
+	session := sessionFromContext(c)
+	loc, err := h.teamWorkplaceLocation(c, session.TeamID, c.Param("id"))
+	if err != nil {
+		return fmt.Errorf("勤怠記録の取得に失敗しました: %w", err)
+	}
+
+	from, err := parseRangeBound(c.QueryParam("from"), loc, false)
+	if err != nil {
+		return validationError("from の形式が不正です: " + err.Error())
+	}
+	to, err := parseRangeBound(c.QueryParam("to"), loc, true)
+	if err != nil {
+		return validationError("to の形式が不正です: " + err.Error())
+	}
+
+	logs, err := h.usecase.GetMemberAttendanceLogs(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), c.QueryParam("user_id"), from, to)
+	if err != nil {
+		return fmt.Errorf("勤怠記録の取得に失敗しました: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, AttendanceRangeResponse{
+		AttendanceLogs: logs,
+		From:           from.In(loc).Format(time.RFC3339),
+		To:             to.In(loc).Format(time.RFC3339),
+		Timezone:       loc.String(),
+		Message:        "勤怠記録を取得しました",
+		Success:        true,
+	})
+}
+
+// AddMemberAttendance はメンバーの勤怠記録を追加する。退勤の打刻忘れなどを補うために使う。
+func (h *Handler) AddMemberAttendance(c echo.Context) error {
+	var req AddMemberAttendanceRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("リクエストの形式が不正です")
+	}
+	if req.UserID == "" {
+		return validationError("user_id を指定してください")
+	}
+
+	session := sessionFromContext(c)
+	loc, err := h.teamWorkplaceLocation(c, session.TeamID, c.Param("id"))
+	if err != nil {
+		return fmt.Errorf("勤怠記録の追加に失敗しました: %w", err)
+	}
+	timestamp, err := time.ParseInLocation("2006-01-02 15:04", req.DateTime, loc)
+	if err != nil {
+		return validationError("時刻の形式が不正です。形式: YYYY-MM-DD HH:MM")
+	}
+
+	attendanceLog, err := h.usecase.AddMemberAttendanceLog(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), req.UserID, req.Action, timestamp)
+	if err != nil {
+		return fmt.Errorf("勤怠記録の追加に失敗しました: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, AttendanceResponse{
+		AttendanceLog: attendanceLog,
+		Message:       "勤怠記録を追加しました ID: " + attendanceLog.ID,
+		Success:       true,
+	})
+}
+
+// EditMemberAttendance はメンバーの勤怠記録の時刻を変更する。
+func (h *Handler) EditMemberAttendance(c echo.Context) error {
+	var req EditMemberAttendanceRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("リクエストの形式が不正です")
+	}
+
+	session := sessionFromContext(c)
+	loc, err := h.teamWorkplaceLocation(c, session.TeamID, c.Param("id"))
+	if err != nil {
+		return fmt.Errorf("勤怠記録の更新に失敗しました: %w", err)
+	}
+	newTime, err := time.ParseInLocation("2006-01-02 15:04", req.NewDateTime, loc)
+	if err != nil {
+		return validationError("時刻の形式が不正です。形式: YYYY-MM-DD HH:MM")
+	}
+
+	updatedLog, err := h.usecase.UpdateMemberAttendanceLog(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), c.Param("log_id"), newTime)
+	if err != nil {
+		return fmt.Errorf("勤怠記録の更新に失敗しました: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, AttendanceResponse{
+		AttendanceLog: updatedLog,
+		Message:       "勤怠記録を更新しました ID: " + updatedLog.ID + " 新しい時刻: " + newTime.Format("2006-01-02 15:04"),
+		Success:       true,
+	})
+}
+
+// DeleteMemberAttendance はメンバーの勤怠記録を削除する。
+func (h *Handler) DeleteMemberAttendance(c echo.Context) error {
+	session := sessionFromContext(c)
+	id := c.Param("log_id")
+	err := h.usecase.DeleteMemberAttendanceLog(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), id)
+	if err != nil {
+		return fmt.Errorf("勤怠記録の削除に失敗しました: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, AttendanceResponse{
+		Message: "勤怠記録を削除しました ID: " + id,
+		Success: true,
+	})
+}
+
+// GetAttendanceAudits はマネージャーが職場の勤怠記録を追加・編集・削除した履歴を返す。
+func (h *Handler) GetAttendanceAudits(c echo.Context) error {
+	session := sessionFromContext(c)
+	loc, err := h.teamWorkplaceLocation(c, session.TeamID, c.Param("id"))
+	if err != nil {
+		return fmt.Errorf("操作履歴の取得に失敗しました: %w", err)
+	}
+
+	from, err := parseRangeBound(c.QueryParam("from"), loc, false)
+	if err != nil {
+		return validationError("from の形式が不正です: " + err.Error())
+	}
+	to, err := parseRangeBound(c.QueryParam("to"), loc, true)
+	if err != nil {
+		return validationError("to の形式が不正です: " + err.Error())
+	}
+
+	audits, err := h.usecase.GetAttendanceAudits(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), from, to)
+	if err != nil {
+		return fmt.Errorf("操作履歴の取得に失敗しました: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, AttendanceAuditsResponse{
+		Audits:   audits,
+		From:     from.In(loc).Format(time.RFC3339),
+		To:       to.In(loc).Format(time.RFC3339),
+		Timezone: loc.String(),
+		Message:  "操作履歴を取得しました",
+		Success:  true,
+	})
+}
+
+// SetWorkplaceRole は職場でのメンバーの役割を変更する。admin だけが変更できる。
+func (h *Handler) SetWorkplaceRole(c echo.Context) error {
+	var req SetWorkplaceRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("リクエストの形式が不正です")
+	}
+
+	session := sessionFromContext(c)
+	workplace, err := h.usecase.SetWorkplaceRole(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), c.Param("user_id"), req.Role)
+	if err != nil {
+		return fmt.Errorf("役割の変更に失敗しました: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, TeamWorkplaceResponse{
+		Workplace: workplace,
+		Message:   fmt.Sprintf("役割を変更しました: %s", workplace.RoleOf(c.Param("user_id"))),
+		Success:   true,
+	})
+}
+
+// teamWorkplaceLocation はチームの職場 workplaceID のタイムゾーンを返す。権限の確認は usecase で行う。
+func (h *Handler) teamWorkplaceLocation(c echo.Context, teamID, workplaceID string) (*time.Location, error) {
+	workplaces, err := h.usecase.GetTeamWorkplaces(c.Request().Context(), teamID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range workplaces {
+		if workplaces[i].ID == workplaceID {
+			return workplaces[i].Location()
+		}
+	}
+
+	return nil, domain.NewError(domain.ErrNotFound, "職場が見つかりません")
+}
@@ -0,0 +1,98 @@
+package presentation
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// oauthStateCookieName は OAuth の state をブラウザに紐付けるための Cookie 名
+	oauthStateCookieName = "slack_oauth_state"
+	// oauthStateTTL は state の有効期間。これを過ぎたコールバックは拒否する。
+	oauthStateTTL = 10 * time.Minute
+)
+
+var (
+	errOAuthStateMissing  = errors.New("oauth state is missing")
+	errOAuthStateInvalid  = errors.New("oauth state is invalid")
+	errOAuthStateExpired  = errors.New("oauth state is expired")
+	errOAuthStateMismatch = errors.New("oauth state does not match")
+)
+
+// newOAuthState は推測できない state を生成する。
+func newOAuthState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func signOAuthState(secret []byte, state string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("oauth_state:" + state + "." + strconv.FormatInt(expiresAt, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encodeOAuthStateCookie は state と有効期限に署名を付けた Cookie の値を返す。
+// 形式: <state>.<有効期限(unix秒)>.<署名>
+func encodeOAuthStateCookie(secret []byte, state string, expiresAt int64) string {
+	return state + "." + strconv.FormatInt(expiresAt, 10) + "." + signOAuthState(secret, state, expiresAt)
+}
+
+// verifyOAuthState は Cookie の署名と有効期限を検証し、コールバックの state と一致するか確認する。
+func verifyOAuthState(secret []byte, cookieValue, state string, now time.Time) error {
+	if cookieValue == "" || state == "" {
+		return errOAuthStateMissing
+	}
+
+	parts := strings.Split(cookieValue, ".")
+	if len(parts) != 3 {
+		return errOAuthStateInvalid
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return errOAuthStateInvalid
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signOAuthState(secret, parts[0], expiresAt))) {
+		return errOAuthStateInvalid
+	}
+	if now.Unix() >= expiresAt {
+		return errOAuthStateExpired
+	}
+	if subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		return errOAuthStateMismatch
+	}
+
+	return nil
+}
+
+// newOAuthStateCookie は state を保持する Cookie を返す。maxAge が負の場合は削除用の Cookie になる。
+// フロントエンドは別オリジンから fetch するため、ローカル以外では SameSite=None; Secure にする。
+func newOAuthStateCookie(value string, maxAge int) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    value,
+		Path:     "/auth/slack",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	}
+	if os.Getenv("ENV") == "local" {
+		cookie.Secure = false
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+
+	return cookie
+}
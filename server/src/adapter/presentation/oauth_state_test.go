@@ -0,0 +1,213 @@
+package presentation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuorei/attendance/src/adapter/infrastructure/memory"
+	"github.com/yuorei/attendance/src/driver/clock"
+	"github.com/yuorei/attendance/src/usecase"
+)
+
+// oauthTestServer は Slack OAuth のフローをローカルの Slack のスタブに対して実行するためのもの。
+type oauthTestServer struct {
+	handler *Handler
+	clock   *clock.FixedClock
+	echo    *echo.Echo
+}
+
+func newOAuthTestServer(t *testing.T) *oauthTestServer {
+	t.Helper()
+	t.Setenv("ENV", "local")
+	t.Setenv("SESSION_SECRET", "test-session-secret")
+	t.Setenv("SLACK_CLIENT_ID", "client-id")
+	t.Setenv("SLACK_CLIENT_SECRET", "client-secret")
+	t.Setenv("SLACK_REDIRECT_URI", "http://localhost:8080/auth/slack/callback")
+
+	// Slack の oauth.v2.access のスタブ。code が "valid-code" の場合だけトークンを返す
+	slackStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/oauth.v2.access" || r.FormValue("client_secret") != "client-secret" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("code") != "valid-code" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error": "invalid_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ok":           true,
+			"access_token": "xoxb-bot",
+			"scope":        "channels:read",
+			"team":         map[string]string{"id": "T1", "name": "Team"},
+			"authed_user":  map[string]string{"id": "U1", "access_token": "xoxp-user"},
+		})
+	}))
+	t.Cleanup(slackStub.Close)
+
+	fixedClock := clock.NewFixedClock(time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+	repo := memory.NewMemory()
+	handler := NewHandler(usecase.NewRepository(repo, repo, repo, repo, fixedClock), fixedClock)
+	handler.slackBaseURL = slackStub.URL
+
+	e := echo.New()
+	e.GET("/auth/slack", handler.SlackOAuthLogin)
+	e.GET("/auth/slack/callback", handler.SlackOAuthCallback)
+
+	return &oauthTestServer{handler: handler, clock: fixedClock, echo: e}
+}
+
+// login は /auth/slack を呼び出し、state と state の Cookie を返す。
+func (s *oauthTestServer) login(t *testing.T) (string, *http.Cookie) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.echo.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/slack", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login status = %d, want %d (body: %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode login response: %v", err)
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == oauthStateCookieName {
+			return resp.State, cookie
+		}
+	}
+	t.Fatal("login did not set the state cookie")
+	return "", nil
+}
+
+// callback は Slack からリダイレクトされたブラウザとして /auth/slack/callback を呼び出す。
+func (s *oauthTestServer) callback(t *testing.T, state string, cookie *http.Cookie, code string) (int, map[string]interface{}) {
+	t.Helper()
+	query := url.Values{"state": {state}, "code": {code}}
+	req := httptest.NewRequest(http.MethodGet, "/auth/slack/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	s.echo.ServeHTTP(rec, req)
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode callback response: %v (body: %s)", err, rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+func TestSlackOAuthCallback(t *testing.T) {
+	s := newOAuthTestServer(t)
+	state, cookie := s.login(t)
+
+	status, resp := s.callback(t, state, cookie, "valid-code")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want %d (response: %v)", status, http.StatusOK, resp)
+	}
+	session, _ := resp["session"].(map[string]interface{})
+	token, _ := session["session_token"].(string)
+	parsed, err := parseSessionToken(s.handler.sessionSecret, token, s.clock.Now())
+	if err != nil {
+		t.Fatalf("session_token is invalid: %v", err)
+	}
+	if parsed.TeamID != "T1" || parsed.UserID != "U1" {
+		t.Errorf("session = %s/%s, want T1/U1", parsed.TeamID, parsed.UserID)
+	}
+	slackToken, err := s.handler.usecase.GetSlackToken(context.Background(), "T1", "U1")
+	if err != nil {
+		t.Fatalf("GetSlackToken() error = %v", err)
+	}
+	if slackToken.BotToken != "xoxb-bot" || slackToken.UserToken != "xoxp-user" {
+		t.Errorf("saved tokens = %q/%q, want xoxb-bot/xoxp-user", slackToken.BotToken, slackToken.UserToken)
+	}
+	if _, ok := session["bot_token"]; ok {
+		t.Error("the bot token must not be returned to the browser")
+	}
+}
+
+func TestSlackOAuthCallbackRejectsReplay(t *testing.T) {
+	s := newOAuthTestServer(t)
+	state, cookie := s.login(t)
+
+	if status, resp := s.callback(t, state, cookie, "valid-code"); status != http.StatusOK {
+		t.Fatalf("first callback status = %d, want %d (response: %v)", status, http.StatusOK, resp)
+	}
+	// 盗まれた Cookie と state の組を期限内に再送しても使えない
+	status, resp := s.callback(t, state, cookie, "valid-code")
+	if status != http.StatusBadRequest || resp["error"] != "invalid_state" {
+		t.Errorf("replayed callback = %d %v, want %d invalid_state", status, resp["error"], http.StatusBadRequest)
+	}
+}
+
+func TestSlackOAuthCallbackConsumesStateOnFailedExchange(t *testing.T) {
+	s := newOAuthTestServer(t)
+	state, cookie := s.login(t)
+
+	if status, resp := s.callback(t, state, cookie, "bad-code"); status != http.StatusBadRequest || resp["error"] != "invalid_code" {
+		t.Fatalf("callback with bad code = %d %v, want %d invalid_code", status, resp["error"], http.StatusBadRequest)
+	}
+	status, resp := s.callback(t, state, cookie, "valid-code")
+	if status != http.StatusBadRequest || resp["error"] != "invalid_state" {
+		t.Errorf("retried callback = %d %v, want %d invalid_state", status, resp["error"], http.StatusBadRequest)
+	}
+}
+
+func TestSlackOAuthCallbackRejectsInvalidState(t *testing.T) {
+	tests := []struct {
+		name string
+		// callback を呼ぶ前の操作。呼び出す state と Cookie を返す
+		prepare func(t *testing.T, s *oauthTestServer) (string, *http.Cookie)
+	}{
+		{
+			name: "expired",
+			prepare: func(t *testing.T, s *oauthTestServer) (string, *http.Cookie) {
+				state, cookie := s.login(t)
+				s.clock.Advance(oauthStateTTL)
+				return state, cookie
+			},
+		},
+		{
+			name: "missing cookie",
+			prepare: func(t *testing.T, s *oauthTestServer) (string, *http.Cookie) {
+				state, _ := s.login(t)
+				return state, nil
+			},
+		},
+		{
+			name: "state issued to another browser",
+			prepare: func(t *testing.T, s *oauthTestServer) (string, *http.Cookie) {
+				_, cookie := s.login(t)
+				otherState, _ := s.login(t)
+				return otherState, cookie
+			},
+		},
+		{
+			name: "state not issued by the server",
+			prepare: func(t *testing.T, s *oauthTestServer) (string, *http.Cookie) {
+				state := "forged-state"
+				expiresAt := s.clock.Now().Add(oauthStateTTL).Unix()
+				return state, newOAuthStateCookie(encodeOAuthStateCookie(s.handler.sessionSecret, state, expiresAt), int(oauthStateTTL.Seconds()))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newOAuthTestServer(t)
+			state, cookie := tt.prepare(t, s)
+
+			status, resp := s.callback(t, state, cookie, "valid-code")
+			if status != http.StatusBadRequest || resp["error"] != "invalid_state" {
+				t.Errorf("callback = %d %v, want %d invalid_state", status, resp["error"], http.StatusBadRequest)
+			}
+		})
+	}
+}
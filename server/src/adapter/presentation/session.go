@@ -0,0 +1,141 @@
+package presentation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// sessionTTL はセッショントークンの有効期間
+	sessionTTL = 12 * time.Hour
+	// sessionContextKey は echo.Context にセッションを格納するキー
+	sessionContextKey = "session"
+)
+
+var (
+	errInvalidSessionToken = errors.New("invalid session token")
+	errSessionExpired      = errors.New("session token expired")
+)
+
+// Session は Slack OAuth 完了後に発行するセッショントークン(JWT)のクレーム。
+// REST API は呼び出し元の Slack ユーザーをこの値からのみ判断する。
+type Session struct {
+	TeamID    string `json:"team_id"`
+	UserID    string `json:"user_id"`
+	TeamName  string `json:"team_name"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// jwtHeader は HS256 で署名した JWT のヘッダー部分(base64url)
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func signSession(secret []byte, signingInput string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issueSessionToken は session を HS256 で署名した JWT を返す。
+func issueSessionToken(secret []byte, session Session) (string, error) {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + signSession(secret, signingInput), nil
+}
+
+// parseSessionToken は JWT の署名と有効期限を検証してクレームを返す。
+func parseSessionToken(secret []byte, token string, now time.Time) (*Session, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, errInvalidSessionToken
+	}
+
+	expected := signSession(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, errInvalidSessionToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errInvalidSessionToken
+	}
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, errInvalidSessionToken
+	}
+	if session.TeamID == "" || session.UserID == "" {
+		return nil, errInvalidSessionToken
+	}
+	if now.Unix() >= session.ExpiresAt {
+		return nil, errSessionExpired
+	}
+
+	return &session, nil
+}
+
+// RequireSession は Authorization: Bearer <token> ヘッダーのセッショントークンを検証し、
+// 検証済みのセッションを echo.Context に格納するミドルウェア。
+func (h *Handler) RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if len(h.sessionSecret) == 0 {
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"error":   "config_error",
+				"message": "SESSION_SECRET が設定されていません",
+			})
+		}
+
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || token == "" {
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"error":   "unauthorized",
+				"message": "ログインが必要です",
+			})
+		}
+
+		session, err := parseSessionToken(h.sessionSecret, token, h.clock.Now())
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"error":   "unauthorized",
+				"message": "セッションが無効です。再度ログインしてください: " + err.Error(),
+			})
+		}
+
+		c.Set(sessionContextKey, session)
+		return next(c)
+	}
+}
+
+// sessionFromContext は RequireSession が格納したセッションを返す。
+func sessionFromContext(c echo.Context) *Session {
+	session, _ := c.Get(sessionContextKey).(*Session)
+	return session
+}
+
+// authorizeSession はリクエストで指定された team_id / user_id がセッションの本人と一致するか確認する。
+// 指定が空の場合はセッションの値を使う。他人を指定した場合は false を返す。
+func authorizeSession(c echo.Context, teamID, userID string) (*Session, bool) {
+	session := sessionFromContext(c)
+	if session == nil {
+		return nil, false
+	}
+	if teamID != "" && teamID != session.TeamID {
+		return nil, false
+	}
+	if userID != "" && userID != session.UserID {
+		return nil, false
+	}
+
+	return session, true
+}
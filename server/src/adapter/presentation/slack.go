@@ -1,10 +1,10 @@
 package presentation
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-	"regexp"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,8 +14,10 @@ import (
 )
 
 func (h *Handler) AttendanceSlach(c echo.Context) error {
-	const START = "start"
-	const END = "end"
+	const START = domain.ActionStart
+	const END = domain.ActionEnd
+	const BREAK_START = domain.ActionBreakStart
+	const BREAK_END = domain.ActionBreakEnd
 
 	s, err := slack.SlashCommandParse(c.Request())
 	if err != nil {
@@ -25,53 +27,138 @@ func (h *Handler) AttendanceSlach(c echo.Context) error {
 	var message string
 	switch s.Command {
 	case "/start-work", "/start-work-dev":
-		attendanceLog, err := h.usecase.AddAttendanceLogStart(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, START)
-		if err != nil {
-			fmt.Println("Error: /start-work :", err.Error())
-			return c.JSON(http.StatusOK, slack.Msg{Text: "Failed to add attendance In log: " + err.Error()})
-		}
-		message = fmt.Sprintf("%s: 出勤", attendanceLog.WorkplaceID)
+		// 形式: [職場名]。チャンネルに職場が複数ある場合は職場名を指定するか、表示されるボタンで選ぶ
+		return c.JSON(http.StatusOK, h.slackAttendance(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, s.Text, START))
 	case "/end-work", "/end-work-dev":
-		attendanceLog, err := h.usecase.AddAttendanceLogEnd(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, END)
-		if err != nil {
-			fmt.Println("Error: /end-work :", err.Error())
-			return c.JSON(http.StatusOK, slack.Msg{Text: "Failed to add attendance log: " + err.Error()})
-		}
-		message = fmt.Sprintf("%s: 退勤", attendanceLog.WorkplaceID)
+		return c.JSON(http.StatusOK, h.slackAttendance(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, s.Text, END))
+	case "/break-start", "/break-start-dev":
+		return c.JSON(http.StatusOK, h.slackAttendance(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, s.Text, BREAK_START))
+	case "/break-end", "/break-end-dev":
+		return c.JSON(http.StatusOK, h.slackAttendance(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, s.Text, BREAK_END))
 	case "/subscribe-workplace", "/subscribe-workplace-dev":
-		workspaceName := s.Text
-		_, err := h.usecase.SubscribeWorkplace(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workspaceName)
+		// 形式: <職場名> [タイムゾーン(例: America/New_York)]
+		workspaceName, timezone := parseSubscribeWorkplaceText(s.Text)
+		workplaceBinding, err := h.usecase.SubscribeWorkplace(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workspaceName, timezone)
 		if err != nil {
 			fmt.Println("Error: /subscribe-workplace :", err.Error())
 			return c.JSON(http.StatusOK, slack.Msg{Text: "Failed to add attendance log: " + err.Error()})
 		}
-		message = fmt.Sprintf("職場登録完了: %s", workspaceName)
-	case "/monthly-hours", "/monthly-hours-dev":
-		// 年月の形式はYYYYMM
-		yearMonth := s.Text
-		if yearMonth == "" {
-			// テキストが空の場合、日本時間での現在の年月を使用
-			jst, _ := time.LoadLocation("Asia/Tokyo")
-			now := time.Now().In(jst)
-			yearMonth = now.Format("200601") // YYYYMM format
+		message = fmt.Sprintf("職場登録完了: %s (タイムゾーン: %s)", workspaceName, workplaceBinding.Timezone)
+	case "/unsubscribe-workplace", "/unsubscribe-workplace-dev":
+		// 形式: [職場名]。登録を解除しても勤怠記録は残り、/monthly-hours で職場名を指定すると参照できる
+		workplaceBinding, err := h.usecase.UnsubscribeWorkplace(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, s.Text)
+		if err != nil {
+			fmt.Println("Error: /unsubscribe-workplace :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "職場の登録解除に失敗しました: " + err.Error()})
 		}
-		if len(yearMonth) != 6 {
-			return c.JSON(http.StatusOK, slack.Msg{Text: "年月の形式が不正です。"})
+		message = fmt.Sprintf("職場の登録を解除しました: %s\n勤怠記録は /monthly-hours <YYYYMM> %s で引き続き確認できます。", workplaceBinding.Workplace, workplaceBinding.Workplace)
+	case "/rename-workplace", "/rename-workplace-dev":
+		// 形式: [職場名 ->] <新しい職場名>
+		workplace, newName := parseRenameWorkplaceText(s.Text)
+		if newName == "" {
+			return c.JSON(http.StatusOK, slack.Msg{Text: "使用方法: /rename-workplace [職場名 ->] <新しい職場名>"})
 		}
-		year := yearMonth[:4]
-		month := yearMonth[4:]
-		attendanceLogs, err := h.usecase.GetAttendanceLogListByUserAndMonth(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, year, month)
+		workplaceBinding, err := h.usecase.RenameWorkplace(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workplace, newName)
 		if err != nil {
-			fmt.Println("Error: /monthly-hours :", err.Error())
-			return c.JSON(http.StatusOK, slack.Msg{Text: "Failed to get attendance log: " + err.Error()})
+			fmt.Println("Error: /rename-workplace :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "職場名の変更に失敗しました: " + err.Error()})
 		}
-		if len(attendanceLogs) == 0 {
-			message = "出勤記録がありません。"
-			return c.JSON(http.StatusOK, slack.Msg{Text: message})
+		message = "職場名を変更しました: " + workplaceBinding.Workplace
+	case "/create-workplace", "/create-workplace-dev":
+		// 形式: <職場名> [タイムゾーン]。チームの職場を作り、作ったユーザーが管理者になる
+		name, timezone := parseSubscribeWorkplaceText(s.Text)
+		workplace, err := h.usecase.CreateTeamWorkplace(c.Request().Context(), s.TeamID, s.UserID, name, timezone)
+		if err != nil {
+			fmt.Println("Error: /create-workplace :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "チームの職場の作成に失敗しました: " + err.Error()})
+		}
+		message = fmt.Sprintf("チームの職場を作成しました: %s (タイムゾーン: %s)\nメンバーは勤怠を記録するチャンネルで /join-workplace %s を実行すると参加できます。", workplace.Name, workplace.Timezone, workplace.Name)
+	case "/join-workplace", "/join-workplace-dev":
+		// 形式: [職場名]。職場名を省略した場合はチームの職場の一覧を表示する
+		workplaces, err := h.usecase.GetTeamWorkplaces(c.Request().Context(), s.TeamID)
+		if err != nil {
+			fmt.Println("Error: /join-workplace :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "チームの職場の取得に失敗しました: " + err.Error()})
+		}
+		if strings.TrimSpace(s.Text) == "" {
+			message = formatTeamWorkplaces(workplaces)
+			break
+		}
+		workplace, err := domain.FindWorkplaceByName(workplaces, s.Text)
+		if err != nil {
+			return c.JSON(http.StatusOK, slack.Msg{Text: "職場への参加に失敗しました: " + err.Error() + "\n" + formatTeamWorkplaces(workplaces)})
+		}
+		workplaceBinding, err := h.usecase.JoinWorkplace(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workplace.ID)
+		if err != nil {
+			fmt.Println("Error: /join-workplace :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "職場への参加に失敗しました: " + err.Error()})
+		}
+		message = fmt.Sprintf("職場に参加しました: %s\nこのチャンネルで /start-work などを使って勤怠を記録できます。", workplaceBinding.Workplace)
+	case "/workplace-members", "/workplace-members-dev":
+		// 形式: [職場名]。チャンネルに登録した職場か、チームの職場のメンバーを表示する
+		workplace, err := h.memberWorkplace(c, s.TeamID, s.ChannelID, s.UserID, s.Text)
+		if err != nil {
+			fmt.Println("Error: /workplace-members :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "職場のメンバーの取得に失敗しました: " + err.Error()})
+		}
+		target, members, err := h.usecase.GetWorkplaceMembers(c.Request().Context(), s.TeamID, s.UserID, workplace.ID)
+		if err != nil {
+			fmt.Println("Error: /workplace-members :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "職場のメンバーの取得に失敗しました: " + err.Error()})
+		}
+		// マネージャー以上には誰が勤務中かも表示する
+		message = formatWorkplaceMembers(target, members, target.RoleOf(s.UserID).Includes(domain.RoleManager))
+	case "/workplace-role", "/workplace-role-dev":
+		message = h.slackWorkplaceRole(c, s)
+	case "/member-attendance", "/member-attendance-dev":
+		message = h.slackMemberAttendance(c, s)
+	case "/add-member-attendance", "/add-member-attendance-dev":
+		message = h.slackAddMemberAttendance(c, s)
+	case "/edit-member-attendance", "/edit-member-attendance-dev":
+		message = h.slackEditMemberAttendance(c, s)
+	case "/delete-member-attendance", "/delete-member-attendance-dev":
+		message = h.slackDeleteMemberAttendance(c, s)
+	case "/monthly-hours", "/monthly-hours-dev":
+		// 形式: [YYYYMM] [職場名]。年月が空の場合、職場ごとにそのタイムゾーンで現在を含む給与計算期間の年月を使用。
+		// 職場名を省略した場合は登録中のすべての職場、指定した場合は登録を解除した職場も対象にする
+		yearMonth, workplace := parseMonthlyHoursText(s.Text)
+		if yearMonth != "" {
+			if _, _, ok := splitYearMonth(yearMonth); !ok {
+				return c.JSON(http.StatusOK, slack.Msg{Text: "年月の形式が不正です。"})
+			}
+		}
+		var bindings []domain.WorkplaceBindings
+		if workplace != "" {
+			binding, err := h.usecase.GetWorkplaceBinding(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workplace)
+			if err != nil {
+				fmt.Println("Error: /monthly-hours :", err.Error())
+				return c.JSON(http.StatusOK, slack.Msg{Text: "Failed to get attendance log: " + err.Error()})
+			}
+			bindings = []domain.WorkplaceBindings{*binding}
+		} else {
+			var err error
+			bindings, err = h.usecase.GetWorkplaceBindings(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID)
+			if err != nil {
+				fmt.Println("Error: /monthly-hours :", err.Error())
+				return c.JSON(http.StatusOK, slack.Msg{Text: "Failed to get attendance log: " + err.Error()})
+			}
 		}
 
-		workplaceName := attendanceLogs[0].WorkplaceID
-		message = FormatAttendance(attendanceLogs, workplaceName)
+		// チャンネルに職場が複数ある場合は職場ごとに集計して並べる
+		reports := make([]string, 0, len(bindings))
+		for i := range bindings {
+			report, err := h.buildMonthlyReport(c, s.TeamID, s.ChannelID, s.UserID, &bindings[i], yearMonth)
+			if err != nil {
+				fmt.Println("Error: /monthly-hours :", err.Error())
+				return c.JSON(http.StatusOK, slack.Msg{Text: "Failed to get attendance log: " + err.Error()})
+			}
+			text := report.format()
+			if len(report.logs) == 0 && len(bindings) > 1 {
+				text = fmt.Sprintf("勤務先: %s\n%s\n", bindings[i].Workplace, text)
+			}
+			reports = append(reports, text)
+		}
+		message = strings.Join(reports, "\n")
 	case "/edit-attendance", "/edit-attendance-dev":
 		// 形式: <id> <新しい時刻(YYYY-MM-DD HH:MM)>
 		parts := strings.Fields(s.Text)
@@ -82,13 +169,24 @@ func (h *Handler) AttendanceSlach(c echo.Context) error {
 		id := parts[0]
 		newTimeStr := strings.Join(parts[1:], " ")
 
-		jst, _ := time.LoadLocation("Asia/Tokyo")
-		newTime, err := time.ParseInLocation("2006-01-02 15:04", newTimeStr, jst)
+		loc, err := h.attendanceLogLocation(c, s.TeamID, s.ChannelID, s.UserID, id)
+		if errors.Is(err, domain.ErrForbidden) {
+			return c.JSON(http.StatusOK, slack.Msg{Text: "この勤怠記録を更新する権限がありません。自分の勤怠記録のIDを指定してください。"})
+		}
+		if err != nil {
+			fmt.Println("Error: /edit-attendance :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "勤怠記録の更新に失敗しました: " + err.Error()})
+		}
+		// 入力された時刻は記録の職場のタイムゾーンとして解釈する
+		newTime, err := time.ParseInLocation("2006-01-02 15:04", newTimeStr, loc)
 		if err != nil {
 			return c.JSON(http.StatusOK, slack.Msg{Text: "時刻の形式が不正です。形式: YYYY-MM-DD HH:MM"})
 		}
 
-		updatedLog, err := h.usecase.UpdateAttendanceLog(c.Request().Context(), id, newTime)
+		updatedLog, err := h.usecase.UpdateAttendanceLog(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, id, newTime)
+		if errors.Is(err, domain.ErrForbidden) {
+			return c.JSON(http.StatusOK, slack.Msg{Text: "この勤怠記録を更新する権限がありません。自分の勤怠記録のIDを指定してください。"})
+		}
 		if err != nil {
 			fmt.Println("Error: /edit-attendance :", err.Error())
 			return c.JSON(http.StatusOK, slack.Msg{Text: "勤怠記録の更新に失敗しました: " + err.Error()})
@@ -102,21 +200,87 @@ func (h *Handler) AttendanceSlach(c echo.Context) error {
 			return c.JSON(http.StatusOK, slack.Msg{Text: "使用方法: /delete-attendance <ID>"})
 		}
 
-		err := h.usecase.DeleteAttendanceLog(c.Request().Context(), id)
+		err := h.usecase.DeleteAttendanceLog(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, id)
+		if errors.Is(err, domain.ErrForbidden) {
+			return c.JSON(http.StatusOK, slack.Msg{Text: "この勤怠記録を削除する権限がありません。自分の勤怠記録のIDを指定してください。"})
+		}
 		if err != nil {
 			fmt.Println("Error: /delete-attendance :", err.Error())
 			return c.JSON(http.StatusOK, slack.Msg{Text: "勤怠記録の削除に失敗しました: " + err.Error()})
 		}
 
 		message = fmt.Sprintf("勤怠記録を削除しました\nID: %s", id)
+	case "/workplace-settings", "/workplace-settings-dev":
+		// 形式: [職場名] [<設定名>=<値> ...]。指定が無い場合は現在の設定を表示する
+		workplace, changes, err := parseSettingChanges(s.Text)
+		if err != nil {
+			return c.JSON(http.StatusOK, slack.Msg{Text: err.Error() + "\n使用方法: /workplace-settings [職場名] [timezone=<タイムゾーン>] [attribution=start_day|split_midnight]"})
+		}
+
+		var binding *domain.WorkplaceBindings
+		if len(changes) == 0 {
+			binding, err = h.usecase.GetWorkplaceBinding(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workplace)
+		} else {
+			binding, err = h.usecase.UpdateWorkplaceSettings(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workplace, changes)
+		}
+		if err != nil {
+			fmt.Println("Error: /workplace-settings :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "職場設定の取得・更新に失敗しました: " + err.Error()})
+		}
+
+		message = formatWorkplaceSettings(binding)
+	case "/hourly-wage", "/hourly-wage-dev":
+		// 形式: [職場名] <時給(円)> [適用開始日(YYYY-MM-DD)]。時給の指定が無い場合は時給の履歴を表示する
+		workplace, parts := splitWorkplaceName(s.Text)
+		if len(parts) == 0 {
+			binding, err := h.usecase.GetWorkplaceBinding(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workplace)
+			if err != nil {
+				fmt.Println("Error: /hourly-wage :", err.Error())
+				return c.JSON(http.StatusOK, slack.Msg{Text: "時給の取得に失敗しました: " + err.Error()})
+			}
+			message = formatHourlyWages(binding)
+			break
+		}
+
+		amount, err := strconv.ParseInt(strings.ReplaceAll(parts[0], ",", ""), 10, 64)
+		if err != nil || len(parts) > 2 {
+			return c.JSON(http.StatusOK, slack.Msg{Text: "使用方法: /hourly-wage [職場名] <時給(円)> [適用開始日(YYYY-MM-DD)]"})
+		}
+		var effectiveFrom string
+		if len(parts) == 2 {
+			effectiveFrom = parts[1]
+		}
+
+		binding, err := h.usecase.SetHourlyWage(c.Request().Context(), s.TeamID, s.ChannelID, s.UserID, workplace, amount, effectiveFrom)
+		if err != nil {
+			fmt.Println("Error: /hourly-wage :", err.Error())
+			return c.JSON(http.StatusOK, slack.Msg{Text: "時給の登録に失敗しました: " + err.Error()})
+		}
+		message = "時給を登録しました\n" + formatHourlyWages(binding)
 	case "/help-attendance", "/help-attendance-dev":
 		message = "以下のコマンドが利用できます。\n" +
-			"/start-work: 出勤\n" +
-			"/end-work: 退勤\n" +
-			"/subscribe-workplace: 職場登録\n" +
-			"/monthly-hours: 月間出勤時間\n" +
+			"/start-work [職場名]: 出勤\n" +
+			"/end-work [職場名]: 退勤\n" +
+			"/break-start [職場名]: 休憩開始\n" +
+			"/break-end [職場名]: 休憩終了\n" +
+			"/subscribe-workplace <職場名> [タイムゾーン]: 職場登録（タイムゾーン省略時は Asia/Tokyo。1つのチャンネルに複数登録できます）\n" +
+			"/unsubscribe-workplace [職場名]: 職場の登録解除（勤怠記録は残ります）\n" +
+			"/rename-workplace [職場名 ->] <新しい職場名>: 職場名の変更\n" +
+			"/create-workplace <職場名> [タイムゾーン]: チームの職場の作成（作成したユーザーが管理者になり、職場名・設定・時給と役割を変更できます）\n" +
+			"/join-workplace [職場名]: チームの職場への参加（職場名を省略すると一覧を表示）\n" +
+			"/workplace-members [職場名]: 職場のメンバーの表示（マネージャー以上には勤務中かも表示）\n" +
+			"/workplace-role <@ユーザー> <member|manager|admin> [職場名]: メンバーの役割の変更（管理者のみ）\n" +
+			"/monthly-hours [YYYYMM] [職場名]: 月間出勤時間（職場ごと。締め日を設定した職場はその月に締める期間。職場名を指定すると登録を解除した職場も確認できます）\n" +
+			"/workplace-settings [職場名] [<設定名>=<値> ...]: 職場設定の表示・変更\n" +
+			"/hourly-wage [職場名] [<時給> [適用開始日]]: 時給の表示・登録\n" +
+			"※ チャンネルに職場が複数ある場合、職場名を省略するとボタンで選べます（退勤・休憩は勤務中の職場が1つならその職場）\n" +
 			"/edit-attendance <ID> <時刻>: 勤怠記録の編集\n" +
 			"/delete-attendance <ID>: 勤怠記録の削除\n" +
+			"以下はチームの職場のマネージャー以上が使えます（操作したマネージャーが記録に残ります）\n" +
+			"/member-attendance <@ユーザー> [YYYYMM] [職場名]: メンバーの勤怠記録の表示\n" +
+			"/add-member-attendance <@ユーザー> <出勤|退勤|休憩開始|休憩終了> <YYYY-MM-DD HH:MM> [職場名]: メンバーの勤怠記録の追加（打刻忘れの修正）\n" +
+			"/edit-member-attendance <ID> <時刻>: メンバーの勤怠記録の編集\n" +
+			"/delete-member-attendance <ID>: メンバーの勤怠記録の削除\n" +
 			"/help-attendance: ヘルプ"
 	default:
 		message = "不明なコマンドです。"
@@ -125,125 +289,534 @@ func (h *Handler) AttendanceSlach(c echo.Context) error {
 	return c.JSON(http.StatusOK, slack.Msg{Text: message})
 }
 
-func FormatAttendance(logs []domain.AttendanceLog, workplaceName string) string {
-	// 1) タイムゾーンつきのレイアウト定義
-	const layout = "2006-01-02 15:04:05.999999999 -0700 MST"
+// parseSubscribeWorkplaceText は "/subscribe-workplace" のテキストを職場名とタイムゾーンに分ける。
+// 最後の単語が "Area/Location" 形式か "UTC" の場合のみタイムゾーンとして扱う。
+func parseSubscribeWorkplaceText(text string) (workplace, timezone string) {
+	fields := strings.Fields(text)
+	if len(fields) >= 2 {
+		last := fields[len(fields)-1]
+		if strings.Contains(last, "/") || last == "UTC" {
+			return strings.Join(fields[:len(fields)-1], " "), last
+		}
+	}
+
+	return strings.TrimSpace(text), ""
+}
+
+// parseRenameWorkplaceText は "/rename-workplace" のテキストを変更する職場名と新しい職場名に分ける。
+// "->" が無い場合はテキスト全体を新しい職場名とし、変更する職場名は省略したものとする。
+func parseRenameWorkplaceText(text string) (workplace, newName string) {
+	if before, after, ok := strings.Cut(text, "->"); ok {
+		return strings.TrimSpace(before), strings.TrimSpace(after)
+	}
 
-	// m=+... を取り除く正規表現
-	re := regexp.MustCompile(` m=\+.*$`)
+	return "", strings.TrimSpace(text)
+}
 
-	byDate := make(map[string][]struct {
-		t time.Time
-		a string
-	})
+// parseMonthlyHoursText は "/monthly-hours" のテキストを年月と職場名に分ける。
+// 最初の単語が数字だけの場合は年月、残りを職場名とする。
+func parseMonthlyHoursText(text string) (yearMonth, workplace string) {
+	fields := strings.Fields(text)
+	if len(fields) > 0 && strings.Trim(fields[0], "0123456789") == "" {
+		return fields[0], strings.Join(fields[1:], " ")
+	}
 
-	// 2) 日付文字列 YYYY-MM-DD を key にグルーピング
-	for _, e := range logs {
-		cleaned := re.ReplaceAllString(e.Timestamp, "")
-		t, err := time.Parse(layout, cleaned)
-		if err != nil {
-			// return "", fmt.Errorf("タイムスタンプの解析失敗: %w", err)
-			return "Error: " + err.Error()
+	return "", strings.Join(fields, " ")
+}
+
+// parseSettingChanges は "職場名 timezone=UTC attribution=split_midnight" のような指定を職場名と、設定名と値に分ける。
+// 最初の "=" を含む単語より前を職場名とする。職場名は省略できる。
+func parseSettingChanges(text string) (string, map[string]string, error) {
+	var name []string
+	changes := make(map[string]string)
+	for _, field := range strings.Fields(text) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok && len(changes) == 0 {
+			name = append(name, field)
+			continue
 		}
-		date := t.Format("2006-01-02")
-		byDate[date] = append(byDate[date], struct {
-			t time.Time
-			a string
-		}{t: t, a: e.Action})
+		if !ok || key == "" {
+			return "", nil, fmt.Errorf("設定の形式が不正です: %s", field)
+		}
+		changes[key] = value
+	}
+
+	return strings.Join(name, " "), changes, nil
+}
+
+// splitWorkplaceName は "職場名 1200 2025-04-01" のようなテキストを、最初の数値より前の職場名と残りの単語に分ける。
+// 数値が無い場合はテキスト全体を職場名とする。
+func splitWorkplaceName(text string) (string, []string) {
+	fields := strings.Fields(text)
+	for i, field := range fields {
+		if _, err := strconv.ParseInt(strings.ReplaceAll(field, ",", ""), 10, 64); err == nil {
+			return strings.Join(fields[:i], " "), fields[i:]
+		}
+	}
+
+	return strings.Join(fields, " "), nil
+}
+
+// formatWorkplaceSettings は職場の設定を Slack 向けの文字列にする。
+func formatWorkplaceSettings(binding *domain.WorkplaceBindings) string {
+	timezone := binding.Timezone
+	loc, err := binding.Location()
+	if err == nil {
+		timezone = loc.String()
+	} else {
+		loc = time.UTC
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("勤務先: %s の設定\n", binding.Workplace))
+	if binding.IsArchived() {
+		sb.WriteString(fmt.Sprintf("（%s に登録を解除済み）\n", binding.DeletedAt.In(loc).Format("2006-01-02")))
+	}
+	sb.WriteString(fmt.Sprintf("・タイムゾーン (timezone): %s\n", timezone))
+	sb.WriteString(fmt.Sprintf("・日付をまたぐ勤務の計上 (attribution): %s\n", attributionLabel(binding.Attribution())))
+	sb.WriteString(fmt.Sprintf("・法定休憩の自動控除 (statutory_break): %s\n", switchLabel(binding.StatutoryBreak)))
+	sb.WriteString(fmt.Sprintf("・時間外労働の割増 25%% (overtime_premium): %s\n", switchLabel(binding.OvertimePremiumEnabled())))
+	sb.WriteString(fmt.Sprintf("・深夜労働の割増 25%% (late_night_premium): %s\n", switchLabel(binding.LateNightPremiumEnabled())))
+	sb.WriteString(fmt.Sprintf("・法定休日労働の割増 35%% (holiday_premium): %s\n", switchLabel(binding.HolidayPremiumEnabled())))
+	sb.WriteString(fmt.Sprintf("・法定休日 (legal_holiday): %s\n", strings.ToLower(binding.LegalHolidayWeekday().String())))
+	sb.WriteString(fmt.Sprintf("・締め日 (closing_day): %s\n", closingDayLabel(binding.ClosingDay)))
+	sb.WriteString(fmt.Sprintf("・打刻の丸め (rounding_unit / rounding_direction): %s\n", roundingLabel(binding.Rounding())))
+
+	return sb.String()
+}
+
+// formatHourlyWages は職場の時給の履歴を Slack 向けの文字列にする。
+func formatHourlyWages(binding *domain.WorkplaceBindings) string {
+	if len(binding.HourlyWages) == 0 {
+		return fmt.Sprintf("勤務先: %s の時給は設定されていません。\n/hourly-wage <時給> [適用開始日] で登録できます。", binding.Workplace)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("勤務先: %s の時給\n", binding.Workplace))
+	for _, w := range binding.HourlyWages {
+		sb.WriteString(fmt.Sprintf("・%s から %s円\n", w.EffectiveFrom, formatYen(w.Amount)))
+	}
+	return sb.String()
+}
+
+func switchLabel(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+func closingDayLabel(day int) string {
+	if day == 0 {
+		return "end（月末締め）"
 	}
+	return fmt.Sprintf("%d（毎月%d日締め）", day, day)
+}
 
-	// 3) 日付順ソート
-	dates := make([]string, 0, len(byDate))
-	for d := range byDate {
-		dates = append(dates, d)
+func roundingLabel(unit time.Duration, direction domain.RoundingDirection) string {
+	if unit <= 0 {
+		return "off（丸めない）"
 	}
-	sort.Strings(dates)
+	var label string
+	switch direction {
+	case domain.RoundingNearest:
+		label = "最も近い時刻"
+	case domain.RoundingDown:
+		label = "労働時間が短くなる方向"
+	case domain.RoundingUp:
+		label = "労働時間が長くなる方向"
+	}
+	return fmt.Sprintf("%d分単位 / %s（%s）", int(unit.Minutes()), direction, label)
+}
+
+func attributionLabel(rule domain.AttributionRule) string {
+	switch rule {
+	case domain.AttributionStartDay:
+		return "start_day（出勤した日に計上）"
+	case domain.AttributionSplitMidnight:
+		return "split_midnight（0時で分割して計上）"
+	}
+	return string(rule)
+}
 
+// formatTeamWorkplaces はチームの職場の一覧を Slack 向けの文字列にする。
+func formatTeamWorkplaces(workplaces []domain.Workplace) string {
+	if len(workplaces) == 0 {
+		return "チームの職場はまだありません。/create-workplace <職場名> で作成できます。"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("チームの職場\n")
+	for _, w := range workplaces {
+		sb.WriteString(fmt.Sprintf("・%s（管理者: <@%s>）\n", w.Name, w.CreatedBy))
+	}
+	sb.WriteString("/join-workplace <職場名> で参加できます。")
+	return sb.String()
+}
+
+// formatWorkplaceMembers は職場のメンバーと役割を Slack 向けの文字列にする。withStatus の場合は勤務中かも添える。
+func formatWorkplaceMembers(workplace *domain.Workplace, members []domain.WorkplaceMember, withStatus bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("勤務先: %s のメンバー (%d人)\n", workplace.Name, len(members)))
+	for i := range members {
+		m := &members[i]
+		sb.WriteString(fmt.Sprintf("・<@%s> (<#%s>) %s", m.UserID, m.ChannelID, roleLabel(m.Role)))
+		if withStatus {
+			sb.WriteString(" " + shiftStatusLabel(m))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// memberWorkplace は /workplace-members やマネージャー向けのコマンドで操作する職場を選ぶ。チャンネルに登録した職場から選び、
+// 職場名を指定してチャンネルに無い場合はチームの職場から探す。
+func (h *Handler) memberWorkplace(c echo.Context, teamID, channelID, userID, workplace string) (*domain.Workplace, error) {
+	binding, err := h.usecase.GetWorkplaceBinding(c.Request().Context(), teamID, channelID, userID, workplace)
+	if err == nil {
+		return &domain.Workplace{ID: binding.WorkplaceID, TeamID: binding.TeamId, Name: binding.Workplace, WorkplaceSettings: binding.WorkplaceSettings}, nil
+	}
+	if !errors.Is(err, domain.ErrNotSubscribed) || strings.TrimSpace(workplace) == "" {
+		return nil, err
+	}
+
+	workplaces, teamErr := h.usecase.GetTeamWorkplaces(c.Request().Context(), teamID)
+	if teamErr != nil {
+		return nil, teamErr
+	}
+	if result, findErr := domain.FindWorkplaceByName(workplaces, workplace); findErr == nil {
+		return result, nil
+	}
+
+	return nil, err
+}
+
+// workplaceLocation は呼び出し元の職場名 workplace の職場と、そのタイムゾーンを返す。
+func (h *Handler) workplaceLocation(c echo.Context, teamID, channelID, userID, workplace string) (*domain.WorkplaceBindings, *time.Location, error) {
+	binding, err := h.usecase.GetWorkplaceBinding(c.Request().Context(), teamID, channelID, userID, workplace)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := binding.Location()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return binding, loc, nil
+}
+
+// attendanceLogLocation は呼び出し元の勤怠記録 id が属する職場のタイムゾーンを返す。
+func (h *Handler) attendanceLogLocation(c echo.Context, teamID, channelID, userID, id string) (*time.Location, error) {
+	binding, err := h.usecase.GetAttendanceLogWorkplace(c.Request().Context(), teamID, channelID, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return binding.Location()
+}
+
+// splitYearMonth は YYYYMM 形式の文字列を年と月に分ける。
+func splitYearMonth(yearMonth string) (year, month string, ok bool) {
+	if len(yearMonth) != 6 {
+		return "", "", false
+	}
+	year, month = yearMonth[:4], yearMonth[4:]
+	if _, _, err := domain.ParseYearMonth(year, month); err != nil {
+		return "", "", false
+	}
+
+	return year, month, true
+}
+
+// sessionLookaround は期間の境界をまたぐ勤務を組にするため、期間の前後に余分に取得する記録の範囲
+const sessionLookaround = 24 * time.Hour
+
+// payrollTimesheet は year年month月に締める給与計算期間の勤怠記録と、それを集計した Timesheet を返す。
+// 期間の前後 sessionLookaround の記録も含めて勤務を組にするので、月末の夜勤も未対応の打刻にならない。
+// 日付をまたぐ勤務の計上や法定休憩の自動控除は職場の設定に従う。
+func (h *Handler) payrollTimesheet(c echo.Context, teamID, channelID, userID string, binding *domain.WorkplaceBindings, year, month string) ([]domain.AttendanceLog, domain.Timesheet, error) {
+	y, m, err := domain.ParseYearMonth(year, month)
+	if err != nil {
+		return nil, domain.Timesheet{}, err
+	}
+	from, to, err := binding.PayrollPeriod(y, m)
+	if err != nil {
+		return nil, domain.Timesheet{}, err
+	}
+
+	logs, err := h.usecase.GetAttendanceLogListByUserAndRange(c.Request().Context(), teamID, channelID, userID, binding.Workplace, from.Add(-sessionLookaround), to.Add(sessionLookaround))
+	if err != nil {
+		return nil, domain.Timesheet{}, err
+	}
+
+	loc := from.Location()
+	timesheet := domain.BuildTimesheet(logs, loc, binding.WorkplaceSettings).Between(from, to, loc)
+
+	periodLogs := make([]domain.AttendanceLog, 0, len(logs))
+	for _, log := range logs {
+		if !log.Timestamp.Before(from) && log.Timestamp.Before(to) {
+			periodLogs = append(periodLogs, log)
+		}
+	}
+
+	return periodLogs, timesheet, nil
+}
+
+// monthlyReport は1つの職場の、給与計算期間1か月分の集計結果。
+type monthlyReport struct {
+	binding   *domain.WorkplaceBindings
+	loc       *time.Location
+	yearMonth string                 // YYYYMM
+	from, to  time.Time              // 給与計算期間 [from, to)
+	logs      []domain.AttendanceLog // 期間内の勤怠記録
+	timesheet domain.Timesheet
+	breakdown domain.HoursBreakdown
+	pay       *domain.PayEstimate // 時給が未設定の職場では nil
+}
+
+// buildMonthlyReport は職場の yearMonth (YYYYMM) に締める給与計算期間の勤怠を集計する。
+// yearMonth が空の場合は、職場のタイムゾーンで現在を含む給与計算期間の年月とする。
+func (h *Handler) buildMonthlyReport(c echo.Context, teamID, channelID, userID string, binding *domain.WorkplaceBindings, yearMonth string) (*monthlyReport, error) {
+	loc, err := binding.Location()
+	if err != nil {
+		return nil, err
+	}
+	if yearMonth == "" {
+		yearMonth = currentPayrollMonth(h.clock.Now().In(loc), binding)
+	}
+	year, month, ok := splitYearMonth(yearMonth)
+	if !ok {
+		return nil, domain.NewError(domain.ErrValidation, "invalid year month %q (YYYYMM)", yearMonth)
+	}
+	// splitYearMonth で検証済みのためエラーにはならない
+	y, m, _ := domain.ParseYearMonth(year, month)
+	from, to := domain.PayrollPeriod(y, m, binding.ClosingDay, loc)
+
+	logs, timesheet, err := h.payrollTimesheet(c, teamID, channelID, userID, binding, year, month)
+	if err != nil {
+		return nil, err
+	}
+	breakdown, pay := classifyHours(timesheet, binding, loc)
+
+	return &monthlyReport{
+		binding:   binding,
+		loc:       loc,
+		yearMonth: yearMonth,
+		from:      from,
+		to:        to,
+		logs:      logs,
+		timesheet: timesheet,
+		breakdown: breakdown,
+		pay:       pay,
+	}, nil
+}
+
+// format は月次レポートを Slack 向けの文字列にする。記録が無い場合はその旨だけを返す。
+func (r *monthlyReport) format() string {
+	if len(r.logs) == 0 {
+		return "出勤記録がありません。"
+	}
+	return FormatAttendance(r.timesheet, r.breakdown, r.pay, r.binding.Workplace, formatPeriodHeader(r.yearMonth, r.binding, r.loc), r.loc)
+}
+
+// classifyHours は労働時間を割増の区分ごとに集計し、時給が設定されている職場の場合のみ支給額も見積もる。
+// 時給が未設定の場合、支給額は nil を返す。
+func classifyHours(timesheet domain.Timesheet, binding *domain.WorkplaceBindings, loc *time.Location) (domain.HoursBreakdown, *domain.PayEstimate) {
+	pieces := domain.ClassifyHours(timesheet, loc, binding.LegalHolidayWeekday())
+	breakdown := domain.SummarizeHours(pieces)
+	if len(binding.HourlyWages) == 0 {
+		return breakdown, nil
+	}
+	pay := domain.EstimatePay(pieces, binding.WorkplaceSettings)
+	return breakdown, &pay
+}
+
+// FormatAttendance は periodHeader で示す期間の勤怠を loc（職場のタイムゾーン）の日付ごとにまとめた文字列を返す。
+// 対にならない打刻は合計に含めず、末尾に一覧で示す。pay が nil でなければ見込み支給額も示す。
+func FormatAttendance(timesheet domain.Timesheet, breakdown domain.HoursBreakdown, pay *domain.PayEstimate, workplaceName, periodHeader string, loc *time.Location) string {
 	var sb strings.Builder
 	// ヘッダー
 	sb.WriteString(fmt.Sprintf("勤務先: %s\n", workplaceName))
 	sb.WriteString("-------------------------------------\n\n")
-	sb.WriteString(fmt.Sprintf("%sの勤怠記録\n", time.Now().Format("1月"))) // 例: 「5月の勤怠記録」
+	sb.WriteString(fmt.Sprintf("%sの勤怠記録\n", periodHeader)) // 例: 「5月の勤怠記録」「6月分（5/26〜6/25）の勤怠記録」
 	sb.WriteString("-------------------------------------\n\n")
 
-	// 月間合計をあとで出すために累計
-	var monthlyTotal float64
-
-	// 4) 各日付ごとに start/end をペアにして表示
-	for _, date := range dates {
-		events := byDate[date]
-		// 時刻順にソート
-		sort.Slice(events, func(i, j int) bool {
-			return events[i].t.Before(events[j].t)
-		})
-
-		// start/end をペアに
-		var dayTotal time.Duration
-		pairs := make([]struct {
-			start, end time.Time
-			dur        time.Duration
-		}, 0, len(events)/2)
-
-		for i := 0; i < len(events)-1; i++ {
-			if events[i].a == "start" && events[i+1].a == "end" {
-				d := events[i+1].t.Sub(events[i].t)
-				pairs = append(pairs, struct {
-					start, end time.Time
-					dur        time.Duration
-				}{
-					start: events[i].t,
-					end:   events[i+1].t,
-					dur:   d,
-				})
-				dayTotal += d
-				i++ // 次はペアの end をスキップ
+	// 各日付ごとに、その日に計上する勤務を表示
+	for _, day := range timesheet.Days {
+		sb.WriteString(fmt.Sprintf("日付: %s\n", day.Date))
+		for _, segment := range day.Segments {
+			sb.WriteString(fmt.Sprintf("・%s / %s（%s）\n",
+				formatSegmentStart(segment, loc),
+				formatSegmentEnd(segment, day.Date, loc),
+				formatDuration(segment.Duration())))
+			for _, b := range segment.Session.Breaks {
+				// 0時で分割した場合は、この区間に重なる休憩だけを表示する
+				if !b.End.Timestamp.After(segment.Start) || !b.Start.Timestamp.Before(segment.End) {
+					continue
+				}
+				sb.WriteString(fmt.Sprintf("　休憩 %s (ID:%s) - %s (ID:%s)\n",
+					b.Start.Timestamp.In(loc).Format("15:04"), b.Start.ID,
+					b.End.Timestamp.In(loc).Format("15:04"), b.End.ID))
 			}
 		}
-		monthlyTotal += dayTotal.Hours()
-
-		// セクション出力
-		sb.WriteString(fmt.Sprintf("日付: %s\n", date))
-		for _, p := range pairs {
-			h := int(p.dur.Hours())
-			m := int(p.dur.Minutes()) % 60
+		sb.WriteString(fmt.Sprintf("合計: %s%s\n", formatDuration(day.Total()), formatDeductions(day.BreakTotal(), day.StatutoryBreakTotal(), day.WorkedTotal())))
+		sb.WriteString("-------------------------------------\n\n")
+	}
 
-			// IDを表示するためにイベントリストから該当するIDを取得
-			var startID, endID string
+	// 月間合計
+	sb.WriteString(fmt.Sprintf("月間合計勤務時間: %s\n", formatDuration(timesheet.Total())))
+	if breakTotal := timesheet.BreakTotal(); breakTotal > 0 {
+		sb.WriteString(fmt.Sprintf("月間合計休憩時間: %s\n", formatDuration(breakTotal)))
+	}
+	if statutoryBreak := timesheet.StatutoryBreakTotal(); statutoryBreak > 0 {
+		sb.WriteString(fmt.Sprintf("法定休憩の自動控除: %s（控除前 %s → 控除後 %s）\n",
+			formatDuration(statutoryBreak), formatDuration(timesheet.WorkedTotal()), formatDuration(timesheet.Total())))
+	}
 
-			// ログから対応するIDを検索
-			for _, log := range logs {
-				logTime := parseTime(log.Timestamp)
-				if logTime.Equal(p.start) && log.Action == "start" {
-					startID = log.ID
-				}
-				if logTime.Equal(p.end) && log.Action == "end" {
-					endID = log.ID
-				}
-			}
+	if timesheet.Total() > 0 {
+		sb.WriteString("\n労働時間の内訳\n")
+		sb.WriteString(fmt.Sprintf("・通常: %s\n", formatDuration(breakdown.Regular)))
+		sb.WriteString(fmt.Sprintf("・時間外: %s\n", formatDuration(breakdown.Overtime)))
+		sb.WriteString(fmt.Sprintf("・法定休日: %s\n", formatDuration(breakdown.Holiday)))
+		sb.WriteString(fmt.Sprintf("・深夜（22時〜5時、上記と重複）: %s\n", formatDuration(breakdown.LateNight)))
+	}
 
-			sb.WriteString(fmt.Sprintf("・出勤 %02d:%02d (ID:%s) / 退勤 %02d:%02d (ID:%s)（%d時間%d分）\n",
-				p.start.Hour(), p.start.Minute(), startID,
-				p.end.Hour(), p.end.Minute(), endID,
-				h, m))
+	if pay != nil {
+		sb.WriteString(fmt.Sprintf("\n見込み支給額（額面）: %s円\n", formatYen(pay.GrossPay)))
+		if pay.PremiumPay > 0 {
+			sb.WriteString(fmt.Sprintf("うち割増賃金: %s円\n", formatYen(pay.PremiumPay)))
+		}
+		if pay.UnpricedDuration > 0 {
+			sb.WriteString(fmt.Sprintf("※ 時給が設定されていない日の勤務 %s は含まれていません\n", formatDuration(pay.UnpricedDuration)))
 		}
-		hTotal := int(dayTotal.Hours())
-		mTotal := int(dayTotal.Minutes()) % 60
-		sb.WriteString(fmt.Sprintf("合計: %d時間%d分\n", hTotal, mTotal))
-		sb.WriteString("-------------------------------------\n\n")
 	}
 
-	// 5) 月間合計
-	totalH := int(monthlyTotal)
-	totalM := int((monthlyTotal - float64(totalH)) * 60)
-	sb.WriteString(fmt.Sprintf("月間合計勤務時間: %d時間%d分\n", totalH, totalM))
+	if len(timesheet.Unmatched) > 0 {
+		sb.WriteString("\n⚠ 対になる打刻が無い記録（合計に含まれていません）\n")
+		for _, event := range timesheet.Unmatched {
+			t := event.Log.Timestamp.In(loc)
+			sb.WriteString(fmt.Sprintf("・%s %s (ID:%s): %s\n",
+				t.Format("2006-01-02 15:04"), actionLabel(event.Log.Action), event.Log.ID, unmatchedReasonLabel(event.Reason)))
+		}
+	}
 
 	return sb.String()
 }
 
-func parseTime(timestamp string) time.Time {
-	const layout = "2006-01-02 15:04:05.999999999 -0700 MST"
-	re := regexp.MustCompile(` m=\+.*$`)
-	cleaned := re.ReplaceAllString(timestamp, "")
-	t, _ := time.Parse(layout, cleaned)
-	return t
+// formatSegmentStart は区間の開始を表示する。前日からの続きの場合は打刻ではないことを示す。
+func formatSegmentStart(segment domain.WorkSegment, loc *time.Location) string {
+	if !segment.Start.Equal(segment.Session.Start.Timestamp) {
+		return fmt.Sprintf("%s (前日から継続)", segment.Start.In(loc).Format("15:04"))
+	}
+	return fmt.Sprintf("出勤 %s (ID:%s)", segment.Start.In(loc).Format("15:04"), segment.Session.Start.ID)
+}
+
+// formatSegmentEnd は区間の終了を表示する。翌日以降の退勤や翌日への継続はその旨を付ける。
+func formatSegmentEnd(segment domain.WorkSegment, date string, loc *time.Location) string {
+	end := segment.End.In(loc)
+	if !segment.End.Equal(segment.Session.End.Timestamp) {
+		return "24:00 (翌日へ継続)"
+	}
+
+	prefix := ""
+	if end.Format("2006-01-02") != date {
+		prefix = end.Format("1/2") + " "
+	}
+	return fmt.Sprintf("退勤 %s%s (ID:%s)", prefix, end.Format("15:04"), segment.Session.End.ID)
+}
+
+// formatDeductions は合計の後ろに付ける、差し引いた休憩の説明を返す。
+func formatDeductions(recorded, statutory, worked time.Duration) string {
+	switch {
+	case statutory > 0 && recorded > 0:
+		return fmt.Sprintf("（休憩 %s を除く。控除前 %s、法定休憩の不足分 %s を自動控除）", formatDuration(recorded), formatDuration(worked), formatDuration(statutory))
+	case statutory > 0:
+		return fmt.Sprintf("（控除前 %s、法定休憩 %s を自動控除）", formatDuration(worked), formatDuration(statutory))
+	case recorded > 0:
+		return fmt.Sprintf("（休憩 %s を除く）", formatDuration(recorded))
+	}
+	return ""
+}
+
+// formatYen は金額を 3 桁区切りにする。
+func formatYen(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(d)
+	}
+	return sign + sb.String()
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%d時間%d分", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func actionLabel(action string) string {
+	switch action {
+	case domain.ActionStart:
+		return "出勤"
+	case domain.ActionEnd:
+		return "退勤"
+	case domain.ActionBreakStart:
+		return "休憩開始"
+	case domain.ActionBreakEnd:
+		return "休憩終了"
+	}
+	return action
+}
+
+func unmatchedReasonLabel(reason domain.UnmatchedReason) string {
+	switch reason {
+	case domain.UnmatchedMissingEnd:
+		return "退勤の打刻がありません"
+	case domain.UnmatchedMissingStart:
+		return "出勤の打刻がありません"
+	case domain.UnmatchedMissingBreakEnd:
+		return "休憩終了の打刻がありません（休憩時間として差し引いていません）"
+	case domain.UnmatchedMissingBreakStart:
+		return "休憩開始の打刻がありません"
+	case domain.UnmatchedOutsideShift:
+		return "出勤・退勤が揃った勤務の中にありません"
+	}
+	return string(reason)
+}
+
+// currentPayrollMonth は now を含む給与計算期間の年月を YYYYMM 形式で返す。
+func currentPayrollMonth(now time.Time, binding *domain.WorkplaceBindings) string {
+	y, m := domain.PayrollMonthOf(now, binding.ClosingDay)
+	return fmt.Sprintf("%04d%02d", y, int(m))
+}
+
+// formatPeriodHeader は月次レポートの見出しにする期間を返す。
+// 月末締めの職場は「5月」、それ以外は「6月分（5/26〜6/25）」のように期間を添える。
+func formatPeriodHeader(yearMonth string, binding *domain.WorkplaceBindings, loc *time.Location) string {
+	header := formatMonthHeader(yearMonth)
+	if binding.ClosingDay == 0 || len(yearMonth) != 6 {
+		return header
+	}
+	y, m, err := domain.ParseYearMonth(yearMonth[:4], yearMonth[4:])
+	if err != nil {
+		return header
+	}
+	from, to := domain.PayrollPeriod(y, m, binding.ClosingDay, loc)
+	return fmt.Sprintf("%s分（%s〜%s）", header, from.Format("1/2"), to.AddDate(0, 0, -1).Format("1/2"))
+}
+
+// formatMonthHeader は YYYYMM を「5月」のような表記にする。解釈できない場合はそのまま返す。
+func formatMonthHeader(yearMonth string) string {
+	if len(yearMonth) == 6 {
+		if _, m, err := domain.ParseYearMonth(yearMonth[:4], yearMonth[4:]); err == nil {
+			return fmt.Sprintf("%d月", m)
+		}
+	}
+
+	return yearMonth
 }
@@ -0,0 +1,142 @@
+package presentation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/slack-go/slack"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// workplacePickerActionPrefix は職場を選ぶボタンの action_id の接頭辞。後ろに打刻の action を付ける。
+const workplacePickerActionPrefix = "select_workplace:"
+
+// slackAttendanceCommands は打刻の action ごとのスラッシュコマンド名と、失敗したときのメッセージ
+var slackAttendanceCommands = map[string]struct {
+	command       string
+	failurePrefix string
+}{
+	domain.ActionStart:      {"/start-work", "Failed to add attendance In log: "},
+	domain.ActionEnd:        {"/end-work", "Failed to add attendance log: "},
+	domain.ActionBreakStart: {"/break-start", "Failed to add break log: "},
+	domain.ActionBreakEnd:   {"/break-end", "Failed to add break log: "},
+}
+
+// addAttendanceLog は action に応じた打刻を記録する。
+func (h *Handler) addAttendanceLog(ctx context.Context, teamID, channelID, userID, workplace, action string) (*domain.AttendanceLog, error) {
+	switch action {
+	case domain.ActionStart:
+		return h.usecase.AddAttendanceLogStart(ctx, teamID, channelID, userID, workplace, action)
+	case domain.ActionEnd:
+		return h.usecase.AddAttendanceLogEnd(ctx, teamID, channelID, userID, workplace, action)
+	case domain.ActionBreakStart:
+		return h.usecase.AddAttendanceLogBreakStart(ctx, teamID, channelID, userID, workplace, action)
+	case domain.ActionBreakEnd:
+		return h.usecase.AddAttendanceLogBreakEnd(ctx, teamID, channelID, userID, workplace, action)
+	}
+	return nil, domain.NewError(domain.ErrValidation, "invalid action %q", action)
+}
+
+// slackAttendance は Slack からの打刻を記録し、応答するメッセージを返す。
+// 職場名を省略して対象の職場が決まらない場合は、職場を選ぶボタンを返す。
+func (h *Handler) slackAttendance(ctx context.Context, teamID, channelID, userID, workplace, action string) slack.Msg {
+	cmd := slackAttendanceCommands[action]
+
+	attendanceLog, err := h.addAttendanceLog(ctx, teamID, channelID, userID, strings.TrimSpace(workplace), action)
+	var ambiguous *domain.AmbiguousWorkplaceError
+	if errors.As(err, &ambiguous) {
+		return workplacePicker(action, ambiguous.Candidates)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s : %s\n", cmd.command, err.Error())
+		return slack.Msg{Text: cmd.failurePrefix + err.Error()}
+	}
+
+	return slack.Msg{Text: fmt.Sprintf("%s: %s", attendanceLog.WorkplaceName, actionLabel(action))}
+}
+
+// workplacePicker は打刻する職場を選ぶボタンのメッセージを返す。ボタンの value は職場名。
+func workplacePicker(action string, workplaces []string) slack.Msg {
+	text := fmt.Sprintf("%sする職場を選んでください。", actionLabel(action))
+	buttons := make([]slack.BlockElement, 0, len(workplaces))
+	for _, name := range workplaces {
+		buttons = append(buttons, slack.NewButtonBlockElement(
+			workplacePickerActionPrefix+action,
+			name,
+			slack.NewTextBlockObject(slack.PlainTextType, name, false, false),
+		))
+	}
+
+	return slack.Msg{
+		Text: text,
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+			slack.NewActionBlock("workplace_picker", buttons...),
+		}},
+	}
+}
+
+// SlackInteraction は Slack のボタン操作を受け取る。職場を選ぶボタンが押されたら、その職場で打刻し
+// response_url を使って元のメッセージを結果に置き換える。
+func (h *Handler) SlackInteraction(c echo.Context) error {
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(c.FormValue("payload")), &callback); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":   "invalid_payload",
+			"message": "Slackのペイロードを解釈できません: " + err.Error(),
+		})
+	}
+	if callback.Type != slack.InteractionTypeBlockActions || len(callback.ActionCallback.BlockActions) == 0 {
+		return c.NoContent(http.StatusOK)
+	}
+
+	action := callback.ActionCallback.BlockActions[0]
+	attendanceAction, ok := strings.CutPrefix(action.ActionID, workplacePickerActionPrefix)
+	if !ok {
+		return c.NoContent(http.StatusOK)
+	}
+
+	ctx := c.Request().Context()
+	msg := h.slackAttendance(ctx, callback.Team.ID, callback.Channel.ID, callback.User.ID, action.Value, attendanceAction)
+	msg.ReplaceOriginal = true
+	if err := h.respondToSlack(ctx, callback.ResponseURL, msg); err != nil {
+		log.Printf("Error: failed to respond to Slack interaction: %v", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// respondToSlack は response_url にメッセージを送る。
+func (h *Handler) respondToSlack(ctx context.Context, responseURL string, msg slack.Msg) error {
+	if responseURL == "" {
+		return errors.New("response_url is empty")
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response_url returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package presentation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/slack-go/slack"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// マネージャー向けのスラッシュコマンド。チームの職場のメンバーの勤怠記録を参照・修正し、メンバーの役割を変更する。
+// ユーザーはメンションで指定する（Slack アプリのスラッシュコマンドで "Escape channels, users, and links" を有効にすること）。
+
+// slackMemberAttendance は "/member-attendance <@ユーザー> [YYYYMM] [職場名]" を処理する。
+// 年月を省略した場合は職場のタイムゾーンで現在を含む給与計算期間の記録を表示する。
+func (h *Handler) slackMemberAttendance(c echo.Context, s slack.SlashCommand) string {
+	const usage = "使用方法: /member-attendance <@ユーザー> [YYYYMM] [職場名]"
+	fields := strings.Fields(s.Text)
+	if len(fields) == 0 {
+		return usage
+	}
+	memberID, ok := parseSlackUserID(fields[0])
+	if !ok {
+		return usage
+	}
+	yearMonth, workplaceName := parseMonthlyHoursText(strings.Join(fields[1:], " "))
+
+	workplace, err := h.memberWorkplace(c, s.TeamID, s.ChannelID, s.UserID, workplaceName)
+	if err != nil {
+		fmt.Println("Error: /member-attendance :", err.Error())
+		return "勤怠記録の取得に失敗しました: " + err.Error()
+	}
+	loc, err := workplace.Location()
+	if err != nil {
+		return "勤怠記録の取得に失敗しました: " + err.Error()
+	}
+	if yearMonth == "" {
+		y, m := domain.PayrollMonthOf(h.clock.Now().In(loc), workplace.ClosingDay)
+		yearMonth = fmt.Sprintf("%04d%02d", y, int(m))
+	}
+	year, month, ok := splitYearMonth(yearMonth)
+	if !ok {
+		return "年月の形式が不正です。"
+	}
+	// splitYearMonth で検証済みのためエラーにはならない
+	y, m, _ := domain.ParseYearMonth(year, month)
+	from, to, err := workplace.PayrollPeriod(y, m)
+	if err != nil {
+		return "勤怠記録の取得に失敗しました: " + err.Error()
+	}
+
+	logs, err := h.usecase.GetMemberAttendanceLogs(c.Request().Context(), s.TeamID, s.UserID, workplace.ID, memberID, from, to)
+	if err != nil {
+		fmt.Println("Error: /member-attendance :", err.Error())
+		return "勤怠記録の取得に失敗しました: " + err.Error()
+	}
+
+	header := fmt.Sprintf("%s（%s〜%s）", formatMonthHeader(yearMonth), from.Format("1/2"), to.AddDate(0, 0, -1).Format("1/2"))
+	return formatMemberAttendanceLogs(workplace.Name, memberID, header, logs, loc)
+}
+
+// slackAddMemberAttendance は "/add-member-attendance <@ユーザー> <種類> <YYYY-MM-DD HH:MM> [職場名]" を処理する。
+func (h *Handler) slackAddMemberAttendance(c echo.Context, s slack.SlashCommand) string {
+	const usage = "使用方法: /add-member-attendance <@ユーザー> <出勤|退勤|休憩開始|休憩終了> <YYYY-MM-DD HH:MM> [職場名]"
+	fields := strings.Fields(s.Text)
+	if len(fields) < 4 {
+		return usage
+	}
+	memberID, ok := parseSlackUserID(fields[0])
+	if !ok {
+		return usage
+	}
+	action, ok := parseActionText(fields[1])
+	if !ok {
+		return usage
+	}
+
+	workplace, err := h.memberWorkplace(c, s.TeamID, s.ChannelID, s.UserID, strings.Join(fields[4:], " "))
+	if err != nil {
+		fmt.Println("Error: /add-member-attendance :", err.Error())
+		return "勤怠記録の追加に失敗しました: " + err.Error()
+	}
+	loc, err := workplace.Location()
+	if err != nil {
+		return "勤怠記録の追加に失敗しました: " + err.Error()
+	}
+	// 入力された時刻は職場のタイムゾーンとして解釈する
+	timestamp, err := time.ParseInLocation("2006-01-02 15:04", fields[2]+" "+fields[3], loc)
+	if err != nil {
+		return "時刻の形式が不正です。形式: YYYY-MM-DD HH:MM"
+	}
+
+	attendanceLog, err := h.usecase.AddMemberAttendanceLog(c.Request().Context(), s.TeamID, s.UserID, workplace.ID, memberID, action, timestamp)
+	if err != nil {
+		fmt.Println("Error: /add-member-attendance :", err.Error())
+		return "勤怠記録の追加に失敗しました: " + err.Error()
+	}
+
+	return fmt.Sprintf("<@%s> の勤怠記録を追加しました（記録者: <@%s>）\n勤務先: %s\nID: %s\n%s: %s",
+		memberID, s.UserID, attendanceLog.WorkplaceName, attendanceLog.ID, actionLabel(action), timestamp.Format("2006-01-02 15:04"))
+}
+
+// slackEditMemberAttendance は "/edit-member-attendance <ID> <YYYY-MM-DD HH:MM>" を処理する。
+func (h *Handler) slackEditMemberAttendance(c echo.Context, s slack.SlashCommand) string {
+	parts := strings.Fields(s.Text)
+	if len(parts) < 2 {
+		return "使用方法: /edit-member-attendance <ID> <新しい時刻(YYYY-MM-DD HH:MM)>"
+	}
+	id := parts[0]
+
+	workplace, err := h.usecase.GetMemberAttendanceLogWorkplace(c.Request().Context(), s.TeamID, s.UserID, id)
+	if err != nil {
+		fmt.Println("Error: /edit-member-attendance :", err.Error())
+		return "勤怠記録の更新に失敗しました: " + err.Error()
+	}
+	loc, err := workplace.Location()
+	if err != nil {
+		return "勤怠記録の更新に失敗しました: " + err.Error()
+	}
+	newTime, err := time.ParseInLocation("2006-01-02 15:04", strings.Join(parts[1:], " "), loc)
+	if err != nil {
+		return "時刻の形式が不正です。形式: YYYY-MM-DD HH:MM"
+	}
+
+	updatedLog, err := h.usecase.UpdateMemberAttendanceLog(c.Request().Context(), s.TeamID, s.UserID, workplace.ID, id, newTime)
+	if err != nil {
+		fmt.Println("Error: /edit-member-attendance :", err.Error())
+		return "勤怠記録の更新に失敗しました: " + err.Error()
+	}
+
+	return fmt.Sprintf("<@%s> の勤怠記録を更新しました（更新者: <@%s>）\nID: %s\n新しい時刻: %s",
+		updatedLog.UserID, s.UserID, updatedLog.ID, newTime.Format("2006-01-02 15:04"))
+}
+
+// slackDeleteMemberAttendance は "/delete-member-attendance <ID>" を処理する。
+func (h *Handler) slackDeleteMemberAttendance(c echo.Context, s slack.SlashCommand) string {
+	id := strings.TrimSpace(s.Text)
+	if id == "" {
+		return "使用方法: /delete-member-attendance <ID>"
+	}
+
+	workplace, err := h.usecase.GetMemberAttendanceLogWorkplace(c.Request().Context(), s.TeamID, s.UserID, id)
+	if err == nil {
+		err = h.usecase.DeleteMemberAttendanceLog(c.Request().Context(), s.TeamID, s.UserID, workplace.ID, id)
+	}
+	if err != nil {
+		fmt.Println("Error: /delete-member-attendance :", err.Error())
+		return "勤怠記録の削除に失敗しました: " + err.Error()
+	}
+
+	return fmt.Sprintf("勤怠記録を削除しました（削除者: <@%s>）\n勤務先: %s\nID: %s", s.UserID, workplace.Name, id)
+}
+
+// slackWorkplaceRole は "/workplace-role <@ユーザー> <member|manager|admin> [職場名]" を処理する。
+func (h *Handler) slackWorkplaceRole(c echo.Context, s slack.SlashCommand) string {
+	const usage = "使用方法: /workplace-role <@ユーザー> <member|manager|admin> [職場名]"
+	fields := strings.Fields(s.Text)
+	if len(fields) < 2 {
+		return usage
+	}
+	memberID, ok := parseSlackUserID(fields[0])
+	if !ok {
+		return usage
+	}
+
+	workplace, err := h.memberWorkplace(c, s.TeamID, s.ChannelID, s.UserID, strings.Join(fields[2:], " "))
+	if err != nil {
+		fmt.Println("Error: /workplace-role :", err.Error())
+		return "役割の変更に失敗しました: " + err.Error()
+	}
+	updated, err := h.usecase.SetWorkplaceRole(c.Request().Context(), s.TeamID, s.UserID, workplace.ID, memberID, fields[1])
+	if err != nil {
+		fmt.Println("Error: /workplace-role :", err.Error())
+		return "役割の変更に失敗しました: " + err.Error()
+	}
+
+	return fmt.Sprintf("勤務先: %s の <@%s> の役割を%sにしました", updated.Name, memberID, roleLabel(updated.RoleOf(memberID)))
+}
+
+// parseSlackUserID はユーザーのメンション (<@U123|name> または <@U123>) からユーザー ID を取り出す。
+// メンションでない場合はユーザー ID がそのまま指定されたものとして扱う。"@name" の形式は ID がわからないため受け付けない。
+func parseSlackUserID(field string) (string, bool) {
+	if strings.HasPrefix(field, "<@") && strings.HasSuffix(field, ">") {
+		id, _, _ := strings.Cut(field[2:len(field)-1], "|")
+		return id, id != ""
+	}
+	if field == "" || strings.HasPrefix(field, "@") {
+		return "", false
+	}
+
+	return field, true
+}
+
+// parseActionText は action の値 (start など) か、その表記 (出勤など) を action に変換する。
+func parseActionText(text string) (string, bool) {
+	for _, action := range []string{domain.ActionStart, domain.ActionEnd, domain.ActionBreakStart, domain.ActionBreakEnd} {
+		if text == action || text == actionLabel(action) {
+			return action, true
+		}
+	}
+
+	return "", false
+}
+
+func roleLabel(role domain.WorkplaceRole) string {
+	switch role {
+	case domain.RoleMember:
+		return "メンバー"
+	case domain.RoleManager:
+		return "マネージャー"
+	case domain.RoleAdmin:
+		return "管理者"
+	}
+	return string(role)
+}
+
+// shiftStatusLabel は最後に記録した action から勤務の状態を返す。
+func shiftStatusLabel(member *domain.WorkplaceMember) string {
+	switch {
+	case member.LastAction == domain.ActionBreakStart:
+		return "休憩中"
+	case member.OnShift():
+		return "勤務中"
+	}
+	return "勤務外"
+}
+
+// formatMemberAttendanceLogs はメンバーの勤怠記録を、編集に使う ID と記録・編集したマネージャーとともに Slack 向けの文字列にする。
+func formatMemberAttendanceLogs(workplaceName, memberID, header string, logs []domain.AttendanceLog, loc *time.Location) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("勤務先: %s <@%s> の勤怠記録 %s\n", workplaceName, memberID, header))
+	if len(logs) == 0 {
+		sb.WriteString("勤怠記録がありません。\n")
+	}
+	for _, log := range logs {
+		sb.WriteString(fmt.Sprintf("・%s %s  ID: %s", log.Timestamp.In(loc).Format("01/02 15:04"), actionLabel(log.Action), log.ID))
+		if log.CreatedBy != "" {
+			sb.WriteString(fmt.Sprintf("（追加: <@%s>）", log.CreatedBy))
+		}
+		if log.UpdatedBy != "" {
+			sb.WriteString(fmt.Sprintf("（編集: <@%s>）", log.UpdatedBy))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("/edit-member-attendance <ID> <時刻> で編集、/delete-member-attendance <ID> で削除できます。")
+	return sb.String()
+}
@@ -0,0 +1,96 @@
+package presentation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxSlackRequestBodySize は署名検証のために読み込むリクエストボディの上限
+const maxSlackRequestBodySize = 1 << 20
+
+// slackSignatureMaxAge は X-Slack-Request-Timestamp と現在時刻のずれの許容範囲。これを超えるリクエストはリプレイとみなす
+const slackSignatureMaxAge = 5 * time.Minute
+
+// slackSignatureVersion は Slack の署名のバージョン
+const slackSignatureVersion = "v0"
+
+// VerifySlackSignature は Slack からのリクエストを署名シークレットで検証するミドルウェア。
+// X-Slack-Signature が一致しないリクエストと、X-Slack-Request-Timestamp が
+// h.clock の時刻から5分以上ずれているリクエスト(リプレイ)を拒否する。
+func (h *Handler) VerifySlackSignature(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if h.slackSigningSecret == "" {
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"error":   "config_error",
+				"message": "SLACK_SIGNING_SECRET が設定されていません",
+			})
+		}
+
+		req := c.Request()
+		signature := req.Header.Get("X-Slack-Signature")
+		timestamp := req.Header.Get("X-Slack-Request-Timestamp")
+		if signature == "" || timestamp == "" {
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"error":   "missing_signature",
+				"message": "Slackリクエストの検証に失敗しました: X-Slack-Signature と X-Slack-Request-Timestamp が必要です",
+			})
+		}
+		sent, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"error":   "invalid_timestamp",
+				"message": "Slackリクエストの検証に失敗しました: X-Slack-Request-Timestamp が不正です",
+			})
+		}
+		if age := h.clock.Now().Sub(time.Unix(sent, 0)); age > slackSignatureMaxAge || age < -slackSignatureMaxAge {
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"error":   "expired_timestamp",
+				"message": "Slackリクエストの検証に失敗しました: タイムスタンプの期限が切れています",
+			})
+		}
+
+		body, err := readAndRestoreBody(c, maxSlackRequestBodySize)
+		if errors.Is(err, errRequestBodyTooLarge) {
+			return slackRequestTooLarge(c)
+		}
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":   "request_read_failed",
+				"message": "リクエストの読み込みに失敗しました: " + err.Error(),
+			})
+		}
+
+		expected := slackSignature(h.slackSigningSecret, timestamp, body)
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"error":   "invalid_signature",
+				"message": "Slackリクエストの署名が一致しません",
+			})
+		}
+
+		return next(c)
+	}
+}
+
+// slackSignature は "v0:<timestamp>:<body>" の HMAC-SHA256 を Slack の署名の形式 ("v0=<hex>") で返す。
+func slackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(slackSignatureVersion + ":" + timestamp + ":"))
+	mac.Write(body)
+	return slackSignatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// slackRequestTooLarge はボディが maxSlackRequestBodySize を超える Slack のリクエストを拒否する。
+func slackRequestTooLarge(c echo.Context) error {
+	return c.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{
+		"error":   "request_too_large",
+		"message": "リクエストボディが大きすぎます",
+	})
+}
@@ -0,0 +1,166 @@
+package presentation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuorei/attendance/src/driver/clock"
+)
+
+const testSlackSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func TestVerifySlackSignature(t *testing.T) {
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	body := "command=%2Fstart-work&team_id=T1&user_id=U1&channel_id=C1"
+	oversized := strings.Repeat("a", maxSlackRequestBodySize+1)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name       string
+		body       string
+		headers    map[string]string
+		elapsed    time.Duration // 署名してからミドルウェアで検証するまでの時間
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "valid signature",
+			body:       body,
+			headers:    signedSlackHeaders(timestamp, body),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "valid signature just before expiry",
+			body:       body,
+			headers:    signedSlackHeaders(timestamp, body),
+			elapsed:    slackSignatureMaxAge,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "tampered body",
+			body:       strings.Replace(body, "U1", "U2", 1),
+			headers:    signedSlackHeaders(timestamp, body),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid_signature",
+		},
+		{
+			name:       "stale timestamp",
+			body:       body,
+			headers:    signedSlackHeaders(timestamp, body),
+			elapsed:    slackSignatureMaxAge + time.Second,
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "expired_timestamp",
+		},
+		{
+			name:       "timestamp in the future",
+			body:       body,
+			headers:    signedSlackHeaders(timestamp, body),
+			elapsed:    -slackSignatureMaxAge - time.Second,
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "expired_timestamp",
+		},
+		{
+			name:       "missing signature header",
+			body:       body,
+			headers:    map[string]string{"X-Slack-Request-Timestamp": timestamp},
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "missing_signature",
+		},
+		{
+			name:       "missing timestamp header",
+			body:       body,
+			headers:    map[string]string{"X-Slack-Signature": signedSlackHeaders(timestamp, body)["X-Slack-Signature"]},
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "missing_signature",
+		},
+		{
+			name:       "body over the limit is rejected instead of truncated",
+			body:       oversized,
+			headers:    signedSlackHeaders(timestamp, oversized),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantError:  "request_too_large",
+		},
+		{
+			name:       "malformed timestamp",
+			body:       body,
+			headers:    signedSlackHeaders("yesterday", body),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid_timestamp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{slackSigningSecret: testSlackSigningSecret, clock: clock.NewFixedClock(now.Add(tt.elapsed))}
+			next := func(c echo.Context) error {
+				// 検証後のハンドラーもボディを読み込めることを確認する
+				read, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return err
+				}
+				return c.String(http.StatusOK, string(read))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			if err := h.VerifySlackSignature(next)(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatalf("VerifySlackSignature() error = %v", err)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantError == "" {
+				if rec.Body.String() != tt.body {
+					t.Errorf("body seen by the handler = %q, want %q", rec.Body.String(), tt.body)
+				}
+				return
+			}
+			var resp map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp["error"] != tt.wantError {
+				t.Errorf("error = %v, want %s", resp["error"], tt.wantError)
+			}
+		})
+	}
+}
+
+func TestVerifySlackSignatureWithoutSecret(t *testing.T) {
+	h := &Handler{clock: clock.NewSystemClock()}
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(""))
+	rec := httptest.NewRecorder()
+	next := func(c echo.Context) error {
+		t.Fatal("next handler must not be called")
+		return nil
+	}
+	if err := h.VerifySlackSignature(next)(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatalf("VerifySlackSignature() error = %v", err)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+// signedSlackHeaders は Slack のドキュメントの手順で、testSlackSigningSecret を使って署名したヘッダーを返す。
+func signedSlackHeaders(timestamp, body string) map[string]string {
+	mac := hmac.New(sha256.New, []byte(testSlackSigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	return map[string]string{
+		"X-Slack-Request-Timestamp": timestamp,
+		"X-Slack-Signature":         "v0=" + hex.EncodeToString(mac.Sum(nil)),
+	}
+}
@@ -0,0 +1,191 @@
+package presentation
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// チームの職場は管理者が作り、メンバーはそれぞれのチャンネルから参加する。
+
+type CreateTeamWorkplaceRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Timezone string `json:"timezone"` // IANA タイムゾーン名。省略時は Asia/Tokyo
+}
+
+type JoinWorkplaceRequest struct {
+	ChannelID string `json:"channel_id" validate:"required"`
+}
+
+// Settings は設定名と値の組。指定しなかった設定は変更しない。
+type TeamWorkplaceSettingsRequest struct {
+	Settings map[string]string `json:"settings" validate:"required"`
+}
+
+// EffectiveFrom は職場のタイムゾーンでの適用開始日 (YYYY-MM-DD)。省略時は今日から適用する。
+type TeamWorkplaceWageRequest struct {
+	HourlyWage    int64  `json:"hourly_wage" validate:"required"`
+	EffectiveFrom string `json:"effective_from"`
+}
+
+type TeamWorkplaceResponse struct {
+	Workplace *domain.Workplace `json:"workplace,omitempty"`
+	Message   string            `json:"message"`
+	Success   bool              `json:"success"`
+}
+
+type TeamWorkplacesResponse struct {
+	Workplaces []domain.Workplace `json:"workplaces"`
+	Message    string             `json:"message"`
+	Success    bool               `json:"success"`
+}
+
+// WorkplaceMemberResponse は職場に登録している Slack ユーザーと、登録したチャンネル、職場での役割。
+// LastAction と OnShift はマネージャー以上が取得した場合だけ返す。
+type WorkplaceMemberResponse struct {
+	UserID     string               `json:"user_id"`
+	ChannelID  string               `json:"channel_id"`
+	Role       domain.WorkplaceRole `json:"role"`
+	JoinedAt   time.Time            `json:"joined_at"`
+	LastAction string               `json:"last_action,omitempty"`
+	OnShift    *bool                `json:"on_shift,omitempty"`
+}
+
+type WorkplaceMembersResponse struct {
+	Members []WorkplaceMemberResponse `json:"members"`
+	Message string                    `json:"message"`
+	Success bool                      `json:"success"`
+}
+
+// CreateTeamWorkplace はチームの職場を作る。作ったユーザーが職場の管理者になる。
+func (h *Handler) CreateTeamWorkplace(c echo.Context) error {
+	var req CreateTeamWorkplaceRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+
+	session := sessionFromContext(c)
+	workplace, err := h.usecase.CreateTeamWorkplace(c.Request().Context(), session.TeamID, session.UserID, req.Name, req.Timezone)
+	if err != nil {
+		return fmt.Errorf("Failed to create workplace: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, TeamWorkplaceResponse{
+		Workplace: workplace,
+		Message:   "チームの職場を作成しました: " + workplace.Name,
+		Success:   true,
+	})
+}
+
+// GetTeamWorkplaces はチームの職場を返す。
+func (h *Handler) GetTeamWorkplaces(c echo.Context) error {
+	session := sessionFromContext(c)
+	workplaces, err := h.usecase.GetTeamWorkplaces(c.Request().Context(), session.TeamID)
+	if err != nil {
+		return fmt.Errorf("Failed to get workplaces: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, TeamWorkplacesResponse{
+		Workplaces: workplaces,
+		Message:    "Successfully retrieved workplaces",
+		Success:    true,
+	})
+}
+
+// JoinWorkplace はチームの職場に参加し、channel_id のチャンネルに登録する。
+func (h *Handler) JoinWorkplace(c echo.Context) error {
+	var req JoinWorkplaceRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+	if req.ChannelID == "" {
+		return validationError("channel_id is required")
+	}
+
+	session := sessionFromContext(c)
+	workplaceBinding, err := h.usecase.JoinWorkplace(c.Request().Context(), session.TeamID, req.ChannelID, session.UserID, c.Param("id"))
+	if err != nil {
+		return fmt.Errorf("Failed to join workplace: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, WorkplaceResponse{
+		WorkplaceBinding: workplaceBinding,
+		Message:          "職場に参加しました: " + workplaceBinding.Workplace,
+		Success:          true,
+	})
+}
+
+// GetWorkplaceMembers は職場に登録している Slack ユーザーの一覧を返す。マネージャー以上には勤務中かも返す。
+func (h *Handler) GetWorkplaceMembers(c echo.Context) error {
+	session := sessionFromContext(c)
+	workplace, members, err := h.usecase.GetWorkplaceMembers(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"))
+	if err != nil {
+		return fmt.Errorf("Failed to get workplace members: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, WorkplaceMembersResponse{
+		Members: newWorkplaceMemberResponses(members, workplace.RoleOf(session.UserID).Includes(domain.RoleManager)),
+		Message: "Successfully retrieved workplace members",
+		Success: true,
+	})
+}
+
+func newWorkplaceMemberResponses(members []domain.WorkplaceMember, withStatus bool) []WorkplaceMemberResponse {
+	result := make([]WorkplaceMemberResponse, 0, len(members))
+	for _, m := range members {
+		member := WorkplaceMemberResponse{UserID: m.UserID, ChannelID: m.ChannelID, Role: m.Role, JoinedAt: m.JoinedAt}
+		if withStatus {
+			onShift := m.OnShift()
+			member.LastAction = m.LastAction
+			member.OnShift = &onShift
+		}
+		result = append(result, member)
+	}
+	return result
+}
+
+// UpdateTeamWorkplaceSettings はチームの職場の設定を変更する。管理者だけが変更できる。
+func (h *Handler) UpdateTeamWorkplaceSettings(c echo.Context) error {
+	var req TeamWorkplaceSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+	if len(req.Settings) == 0 {
+		return validationError("settings is required")
+	}
+
+	session := sessionFromContext(c)
+	workplace, err := h.usecase.UpdateTeamWorkplaceSettings(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), req.Settings)
+	if err != nil {
+		return fmt.Errorf("Failed to update workplace settings: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, TeamWorkplaceResponse{
+		Workplace: workplace,
+		Message:   "職場設定を更新しました: " + workplace.Name,
+		Success:   true,
+	})
+}
+
+// SetTeamWorkplaceHourlyWage はチームの職場の時給を登録する。管理者だけが登録できる。
+func (h *Handler) SetTeamWorkplaceHourlyWage(c echo.Context) error {
+	var req TeamWorkplaceWageRequest
+	if err := c.Bind(&req); err != nil {
+		return validationError("Invalid request format")
+	}
+
+	session := sessionFromContext(c)
+	workplace, err := h.usecase.SetTeamWorkplaceHourlyWage(c.Request().Context(), session.TeamID, session.UserID, c.Param("id"), req.HourlyWage, req.EffectiveFrom)
+	if err != nil {
+		return fmt.Errorf("Failed to set hourly wage: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, TeamWorkplaceResponse{
+		Workplace: workplace,
+		Message:   "時給を登録しました: " + workplace.Name,
+		Success:   true,
+	})
+}
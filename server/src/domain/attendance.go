@@ -2,24 +2,59 @@ package domain
 
 import "time"
 
+// TimestampLayout は timestamp 属性を DynamoDB に保存する際の書式。
+// UTC・ミリ秒精度の固定長 RFC3339 なので、文字列の辞書順と時刻順が一致し
+// gsi_workplace_timestamp のソートキーで範囲検索ができる。
+const TimestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// FormatTimestamp は t を TimestampLayout の文字列に変換する。
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampLayout)
+}
+
 type AttendanceLog struct {
-	ID          string `dynamodbav:"id"`
-	TeamID      string `dynamodbav:"team_id"`
-	UserID      string `dynamodbav:"user_id"`
-	Timestamp   string `dynamodbav:"timestamp"` // DynamoDBの属性タイプがSなのでstringに変更 (time.TimeでもUnmarshal可能だが、クエリとの整合性を考慮)
-	Action      string `dynamodbav:"action"`
-	ChannelID   string `dynamodbav:"channel_id"`
-	WorkplaceID string `dynamodbav:"workplace_id"`
+	ID          string    `dynamodbav:"id"`
+	TeamID      string    `dynamodbav:"team_id"`
+	UserID      string    `dynamodbav:"user_id"`
+	Timestamp   time.Time `dynamodbav:"timestamp"` // DynamoDB上は TimestampLayout の文字列(S)として保存する
+	Action      string    `dynamodbav:"action"`
+	ChannelID   string    `dynamodbav:"channel_id"`
+	WorkplaceID string    `dynamodbav:"workplace_id" json:"workplace_id"` // Workplace の ID
+	// WorkplaceName は WorkplaceID の職場名。保存せず、勤怠記録を返すときに設定する
+	WorkplaceName string `dynamodbav:"-" json:"workplace_name"`
+	// CreatedBy はマネージャーが追加した記録の場合のそのマネージャー。本人が打刻した記録では空
+	CreatedBy string `dynamodbav:"created_by,omitempty" json:"created_by,omitempty"`
+	// UpdatedBy は最後に記録の時刻を編集したユーザー（本人かマネージャー）
+	UpdatedBy string `dynamodbav:"updated_by,omitempty" json:"updated_by,omitempty"`
 }
 
+// IsOwnedBy は勤怠記録が指定した team / channel / user のものかを返す。
+func (l *AttendanceLog) IsOwnedBy(teamID, channelID, userID string) bool {
+	return l.TeamID == teamID && l.ChannelID == channelID && l.UserID == userID
+}
+
+// AttendanceLogPage は勤怠記録を1ページ分取得した結果。NextCursor が空の場合は最後のページ。
+type AttendanceLogPage struct {
+	Logs       []AttendanceLog
+	NextCursor string
+}
+
+// WorkplaceBindings はユーザーがチャンネルで職場 (Workplace) を登録したもの。
 type WorkplaceBindings struct {
-	ID           string     `dynamodbav:"id"`
-	TeamId       string     `dynamodbav:"team_id"`
-	CannelId     string     `dynamodbav:"channel_id"`
-	UserId       string     `dynamodbav:"user_id"`
-	Workplace    string     `dynamodbav:"workplace"`
-	CreatedAt    time.Time  `dynamodbav:"created_at"`
-	UpdatedAt    time.Time  `dynamodbav:"updated_at"`
-	DeletedAt    *time.Time `dynamodbav:"deleted_at"`
-	CompositeKey string     `dynamodbav:"composite_key"`
+	ID          string `dynamodbav:"id"`
+	TeamId      string `dynamodbav:"team_id"`
+	CannelId    string `dynamodbav:"channel_id"`
+	UserId      string `dynamodbav:"user_id"`
+	WorkplaceID string `dynamodbav:"workplace_id"`
+	// Workplace（職場名）と WorkplaceSettings は WorkplaceID の職場のもの。
+	// 保存せず、読み込むときに SetWorkplace で設定する
+	Workplace         string `dynamodbav:"-"`
+	WorkplaceSettings `dynamodbav:"-"`
+	CreatedAt         time.Time  `dynamodbav:"created_at"`
+	UpdatedAt         time.Time  `dynamodbav:"updated_at"`
+	DeletedAt         *time.Time `dynamodbav:"deleted_at"`
+	CompositeKey      string     `dynamodbav:"composite_key"`
+	// LastAction は最後に記録した action。打刻の状態遷移を条件付き書き込みで守るために使う。
+	// 空の場合は不明として、最新の勤怠記録から求める
+	LastAction string `dynamodbav:"last_action,omitempty"`
 }
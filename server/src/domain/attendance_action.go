@@ -0,0 +1,54 @@
+package domain
+
+// 勤怠記録の action の値
+const (
+	ActionStart      = "start"
+	ActionEnd        = "end"
+	ActionBreakStart = "break_start"
+	ActionBreakEnd   = "break_end"
+)
+
+// ValidateTransition は直前の記録 latest（無ければ nil）の後に action を記録できるか確認する。
+// 1回の勤務は start → (break_start → break_end)* → end の順にしか記録できない。
+func ValidateTransition(latest *AttendanceLog, action string) error {
+	var current string
+	if latest != nil {
+		current = latest.Action
+	}
+
+	return ValidateActionTransition(current, action)
+}
+
+// ValidateActionTransition は直前に記録した action が current（記録が無ければ空文字）のときに action を記録できるか確認する。
+func ValidateActionTransition(current, action string) error {
+	switch action {
+	case ActionStart:
+		if current != "" && current != ActionEnd {
+			return ErrAlreadyCheckedIn
+		}
+	case ActionEnd:
+		switch current {
+		case "":
+			return NewError(ErrNotCheckedIn, "no start log found")
+		case ActionEnd:
+			return NewError(ErrNotCheckedIn, "already checked out")
+		case ActionBreakStart:
+			return ErrOnBreak
+		}
+	case ActionBreakStart:
+		switch current {
+		case "", ActionEnd:
+			return ErrNotCheckedIn
+		case ActionBreakStart:
+			return NewError(ErrOnBreak, "already on break")
+		}
+	case ActionBreakEnd:
+		if current != ActionBreakStart {
+			return ErrNotOnBreak
+		}
+	default:
+		return NewError(ErrValidation, "invalid action %q", action)
+	}
+
+	return nil
+}
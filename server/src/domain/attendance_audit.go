@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// AuditOperation はマネージャーが勤怠記録に行った操作。
+type AuditOperation string
+
+const (
+	AuditCreate AuditOperation = "create"
+	AuditUpdate AuditOperation = "update"
+	AuditDelete AuditOperation = "delete"
+)
+
+// AttendanceAudit はマネージャーがメンバーの勤怠記録を追加・編集・削除した履歴。
+// 勤怠記録を削除しても残るので、誰がいつどの記録を変えたかを後から確認できる。
+type AttendanceAudit struct {
+	ID              string         `dynamodbav:"id" json:"id"`
+	TeamID          string         `dynamodbav:"team_id" json:"team_id"`
+	WorkplaceID     string         `dynamodbav:"workplace_id" json:"workplace_id"`
+	ActorID         string         `dynamodbav:"actor_id" json:"actor_id"` // 操作したマネージャー
+	UserID          string         `dynamodbav:"user_id" json:"user_id"`   // 勤怠記録のメンバー
+	Operation       AuditOperation `dynamodbav:"operation" json:"operation"`
+	AttendanceLogID string         `dynamodbav:"attendance_log_id" json:"attendance_log_id"`
+	Action          string         `dynamodbav:"action" json:"action"`
+	// Before と After は操作の前後の記録の時刻。追加では Before、削除では After が無い
+	Before    *time.Time `dynamodbav:"before" json:"before,omitempty"`
+	After     *time.Time `dynamodbav:"after" json:"after,omitempty"`
+	CreatedAt time.Time  `dynamodbav:"created_at" json:"created_at"`
+}
+
+// NewAttendanceAudit は actorID が勤怠記録 log に operation を行った履歴を作る。
+// before と after は操作の前後の記録の時刻で、無い場合は nil を渡す。
+func NewAttendanceAudit(id, actorID string, operation AuditOperation, log *AttendanceLog, before, after *time.Time, createdAt time.Time) AttendanceAudit {
+	return AttendanceAudit{
+		ID:              id,
+		TeamID:          log.TeamID,
+		WorkplaceID:     log.WorkplaceID,
+		ActorID:         actorID,
+		UserID:          log.UserID,
+		Operation:       operation,
+		AttendanceLogID: log.ID,
+		Action:          log.Action,
+		Before:          before,
+		After:           after,
+		CreatedAt:       createdAt,
+	}
+}
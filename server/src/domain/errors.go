@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// エラーの種類を表す sentinel。各層はこれらを %w で包むか NewError で詳しいメッセージを付けて返し、
+// 呼び出し側は errors.Is で種類を判定する。HTTP のステータスコードへの変換は presentation 層で行う。
+var (
+	// ErrNotFound は対象の記録が存在しない場合のエラー。
+	ErrNotFound = errors.New("not found")
+	// ErrNotSubscribed はチャンネルで職場が登録されていない場合のエラー。
+	ErrNotSubscribed = errors.New("workplace is not subscribed")
+	// ErrAlreadySubscribed はチャンネルで同じ名前の職場が登録済みの場合のエラー。
+	ErrAlreadySubscribed = errors.New("already subscribed to workplace")
+	// ErrAmbiguousWorkplace はチャンネルに職場が複数登録されていて、職場名を省略した操作の対象を決められない場合のエラー。
+	ErrAmbiguousWorkplace = errors.New("workplace is ambiguous")
+	// ErrAlreadyCheckedIn は勤務中に出勤しようとした場合のエラー。
+	ErrAlreadyCheckedIn = errors.New("already checked in")
+	// ErrNotCheckedIn は勤務中でないときに退勤・休憩しようとした場合のエラー。
+	ErrNotCheckedIn = errors.New("not checked in")
+	// ErrOnBreak は休憩中に退勤・休憩開始しようとした場合のエラー。
+	ErrOnBreak = errors.New("currently on break")
+	// ErrNotOnBreak は休憩中でないときに休憩を終了しようとした場合のエラー。
+	ErrNotOnBreak = errors.New("not on break")
+	// ErrConflict は他のリクエストと同時に更新しようとして保存できなかった場合のエラー。
+	ErrConflict = errors.New("conflict")
+	// ErrValidation は入力値が不正な場合のエラー。
+	ErrValidation = errors.New("validation error")
+)
+
+// ErrForbidden は操作対象が呼び出し元(team / channel / user)のものではない場合のエラー。
+var ErrForbidden = errors.New("forbidden: the attendance log does not belong to the caller")
+
+// ErrOAuthStateNotFound は OAuth の state が保管されていないか、期限切れ・使用済みの場合のエラー。
+var ErrOAuthStateNotFound = NewError(ErrNotFound, "OAuth state not found or already used")
+
+// ErrInvalidCursor はページ分割のカーソルを解釈できない場合のエラー。
+var ErrInvalidCursor = NewError(ErrValidation, "invalid cursor")
+
+// Error は sentinel の種類に、利用者に見せる個別のメッセージを付けたエラー。
+// Error() はメッセージだけを返し、errors.Is(err, Kind) で種類を判定できる。
+type Error struct {
+	Kind    error
+	Message string
+}
+
+// NewError は kind の種類で、format のメッセージを持つエラーを返す。
+func NewError(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// IdempotencyTTL は同じリクエストに保存した応答を返す期間。
+const IdempotencyTTL = 24 * time.Hour
+
+// IdempotencyLeaseTTL は処理中の記録の有効期間。Lambda のタイムアウトや異常終了で応答を保存できなかった場合も、
+// この期間が過ぎれば同じキーで再試行できる。
+const IdempotencyLeaseTTL = 2 * time.Minute
+
+// IdempotencyStatus はリクエストの処理状況。
+type IdempotencyStatus string
+
+const (
+	// IdempotencyInProgress は最初のリクエストを処理中であることを表す。
+	IdempotencyInProgress IdempotencyStatus = "in_progress"
+	// IdempotencyCompleted は応答を保存済みであることを表す。
+	IdempotencyCompleted IdempotencyStatus = "completed"
+)
+
+var (
+	// ErrIdempotencyInProgress は同じキーのリクエストをまだ処理している場合のエラー。
+	ErrIdempotencyInProgress = errors.New("a request with the same idempotency key is in progress")
+	// ErrIdempotencyKeyMismatch は同じキーで内容の異なるリクエストが送られた場合のエラー。
+	ErrIdempotencyKeyMismatch = errors.New("the idempotency key was used for a different request")
+)
+
+// IdempotencyRecord はリクエストとその応答の記録。同じ Key のリクエストには保存した応答をそのまま返す。
+type IdempotencyRecord struct {
+	Key string
+	// RequestHash はリクエストの内容のハッシュ。同じキーで別のリクエストが送られていないか確認する
+	RequestHash string
+	Status      IdempotencyStatus
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// OAuthStateID は OAuth の state を保管するキーを作る。state は URL に含まれるため、そのままではなくハッシュを保存する。
+func OAuthStateID(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// 労働基準法の割増賃金の基準と割増率（%）
+const (
+	DailyOvertimeThreshold  = 8 * time.Hour
+	WeeklyOvertimeThreshold = 40 * time.Hour
+
+	OvertimePremiumRate  = 25
+	LateNightPremiumRate = 25
+	HolidayPremiumRate   = 35
+)
+
+// lateNightStartHour, lateNightEndHour は深夜労働（22時〜翌5時）の範囲
+const (
+	lateNightStartHour = 22
+	lateNightEndHour   = 5
+)
+
+// WorkPiece は割増の区分が同じ、連続した労働時間。
+type WorkPiece struct {
+	Date      string // 計上する日付 (YYYY-MM-DD)。時給の判定に使う
+	Start     time.Time
+	End       time.Time
+	Overtime  bool // 1日8時間・週40時間を超える時間外労働
+	LateNight bool // 22時〜翌5時の深夜労働
+	Holiday   bool // 法定休日の労働
+}
+
+func (p WorkPiece) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// HoursBreakdown は労働時間の区分ごとの合計。
+// Regular + Overtime + Holiday が実働時間の合計で、LateNight はそれらと重複して数える。
+type HoursBreakdown struct {
+	Regular   time.Duration
+	Overtime  time.Duration
+	LateNight time.Duration
+	Holiday   time.Duration
+}
+
+// timeRange は [Start, End) の区間
+type timeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ClassifyHours は timesheet の実働時間を、時間外・深夜・法定休日の区分ごとに分ける。
+// 1日の区切りは timesheet の計上日、週は日曜始まりとする。
+// 法定休日の労働は時間外労働の集計に含めない。
+// 期間より前の週の労働時間は分からないため、期間の最初の週は期間内の労働だけで週40時間を判定する。
+func ClassifyHours(timesheet Timesheet, loc *time.Location, legalHoliday time.Weekday) []WorkPiece {
+	var pieces []WorkPiece
+	var daily, weekly time.Duration
+	var currentDate, currentWeek string
+
+	for _, day := range timesheet.Days {
+		if day.Date != currentDate {
+			currentDate, daily = day.Date, 0
+		}
+		if week := weekStart(day.Date); week != currentWeek {
+			currentWeek, weekly = week, 0
+		}
+
+		for _, segment := range day.Segments {
+			for _, r := range segmentWorkRanges(segment) {
+				cur := r.Start.In(loc)
+				end := r.End.In(loc)
+				for cur.Before(end) {
+					holiday := cur.Weekday() == legalHoliday
+					overtime := !holiday && (daily >= DailyOvertimeThreshold || weekly >= WeeklyOvertimeThreshold)
+
+					next := minTime(end, nextClockBoundary(cur, loc))
+					if !holiday && !overtime {
+						// 1日8時間・週40時間に達する時刻で区切る
+						next = minTime(next, cur.Add(DailyOvertimeThreshold-daily))
+						next = minTime(next, cur.Add(WeeklyOvertimeThreshold-weekly))
+					}
+
+					pieces = append(pieces, WorkPiece{
+						Date:      day.Date,
+						Start:     cur,
+						End:       next,
+						Overtime:  overtime,
+						LateNight: isLateNight(cur),
+						Holiday:   holiday,
+					})
+					if !holiday {
+						daily += next.Sub(cur)
+						// 1日8時間を超えた時間外労働は週40時間の判定に数えない（二重に割増しないため）
+						if !overtime {
+							weekly += next.Sub(cur)
+						}
+					}
+					cur = next
+				}
+			}
+		}
+	}
+
+	return pieces
+}
+
+// SummarizeHours は区分ごとの合計を返す。
+func SummarizeHours(pieces []WorkPiece) HoursBreakdown {
+	var b HoursBreakdown
+	for _, p := range pieces {
+		d := p.Duration()
+		switch {
+		case p.Holiday:
+			b.Holiday += d
+		case p.Overtime:
+			b.Overtime += d
+		default:
+			b.Regular += d
+		}
+		if p.LateNight {
+			b.LateNight += d
+		}
+	}
+	return b
+}
+
+// segmentWorkRanges は区間から記録された休憩と法定休憩の自動控除を除いた、実際に働いた区間を返す。
+// 自動控除した休憩は、実働時間のちょうど中間で取ったものとみなす。
+func segmentWorkRanges(segment WorkSegment) []timeRange {
+	ranges := []timeRange{{Start: segment.Start, End: segment.End}}
+	breaks := make([]timeRange, 0, len(segment.Session.Breaks))
+	for _, b := range segment.Session.Breaks {
+		breaks = append(breaks, timeRange{Start: b.Start.Timestamp, End: b.End.Timestamp})
+	}
+	sort.Slice(breaks, func(i, j int) bool { return breaks[i].Start.Before(breaks[j].Start) })
+	for _, b := range breaks {
+		ranges = subtractRange(ranges, b)
+	}
+
+	if segment.StatutoryBreak <= 0 {
+		return ranges
+	}
+
+	// 実働時間の中間から StatutoryBreak の長さだけ取り除く
+	var worked time.Duration
+	for _, r := range ranges {
+		worked += r.End.Sub(r.Start)
+	}
+	offset := (worked - segment.StatutoryBreak) / 2
+	remaining := segment.StatutoryBreak
+	for _, r := range ranges {
+		length := r.End.Sub(r.Start)
+		if offset >= length {
+			offset -= length
+			continue
+		}
+		start := r.Start.Add(offset)
+		end := minTime(r.End, start.Add(remaining))
+		ranges = subtractRange(ranges, timeRange{Start: start, End: end})
+		remaining -= end.Sub(start)
+		if remaining <= 0 {
+			break
+		}
+		offset = 0
+	}
+
+	return ranges
+}
+
+// subtractRange は ranges から cut と重なる部分を取り除く。
+func subtractRange(ranges []timeRange, cut timeRange) []timeRange {
+	result := make([]timeRange, 0, len(ranges)+1)
+	for _, r := range ranges {
+		if !cut.Start.Before(r.End) || !cut.End.After(r.Start) {
+			result = append(result, r)
+			continue
+		}
+		if r.Start.Before(cut.Start) {
+			result = append(result, timeRange{Start: r.Start, End: cut.Start})
+		}
+		if cut.End.Before(r.End) {
+			result = append(result, timeRange{Start: cut.End, End: r.End})
+		}
+	}
+	return result
+}
+
+// nextClockBoundary は t の後で、深夜労働の開始・終了か日付が変わる最初の時刻を返す。
+func nextClockBoundary(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.Date()
+	candidates := []time.Time{
+		time.Date(y, m, d, lateNightEndHour, 0, 0, 0, loc),
+		time.Date(y, m, d, lateNightStartHour, 0, 0, 0, loc),
+		time.Date(y, m, d+1, 0, 0, 0, 0, loc),
+	}
+	for _, c := range candidates {
+		if c.After(t) {
+			return c
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func isLateNight(t time.Time) bool {
+	return t.Hour() >= lateNightStartHour || t.Hour() < lateNightEndHour
+}
+
+// weekStart は date (YYYY-MM-DD) を含む週の日曜日を返す。
+func weekStart(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, -int(t.Weekday())).Format("2006-01-02")
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyHours(t *testing.T) {
+	loc := mustLoadLocation(t, "Asia/Tokyo")
+
+	// 2025-06-01 は日曜日
+	tests := []struct {
+		name         string
+		shifts       []string // 出勤と退勤の時刻の組
+		legalHoliday time.Weekday
+		// period が設定されている場合は、締め日で区切った期間 [from, to) に絞り込んでから集計する
+		period []string
+		want   HoursBreakdown
+	}{
+		{
+			name: "daily overtime does not count toward the weekly limit",
+			shifts: []string{
+				"2025-06-02 09:00", "2025-06-02 19:00",
+				"2025-06-03 09:00", "2025-06-03 19:00",
+				"2025-06-04 09:00", "2025-06-04 19:00",
+				"2025-06-05 09:00", "2025-06-05 19:00",
+				"2025-06-06 09:00", "2025-06-06 19:00",
+			},
+			want: HoursBreakdown{Regular: 40 * time.Hour, Overtime: 10 * time.Hour},
+		},
+		{
+			name: "weekly limit after daily overtime",
+			shifts: []string{
+				"2025-06-02 09:00", "2025-06-02 19:00",
+				"2025-06-03 09:00", "2025-06-03 19:00",
+				"2025-06-04 09:00", "2025-06-04 19:00",
+				"2025-06-05 09:00", "2025-06-05 19:00",
+				"2025-06-06 09:00", "2025-06-06 17:00",
+				"2025-06-07 09:00", "2025-06-07 13:00",
+			},
+			want: HoursBreakdown{Regular: 40 * time.Hour, Overtime: 12 * time.Hour},
+		},
+		{
+			name: "weekly limit within a day",
+			shifts: []string{
+				"2025-06-02 09:00", "2025-06-02 16:00",
+				"2025-06-03 09:00", "2025-06-03 17:00",
+				"2025-06-04 09:00", "2025-06-04 17:00",
+				"2025-06-05 09:00", "2025-06-05 17:00",
+				"2025-06-06 09:00", "2025-06-06 17:00",
+				"2025-06-07 09:00", "2025-06-07 13:00",
+			},
+			want: HoursBreakdown{Regular: 40 * time.Hour, Overtime: 3 * time.Hour},
+		},
+		{
+			name:   "late night across 22:00 and 05:00",
+			shifts: []string{"2025-06-02 20:00", "2025-06-03 06:00"},
+			want:   HoursBreakdown{Regular: 8 * time.Hour, Overtime: 2 * time.Hour, LateNight: 7 * time.Hour},
+		},
+		{
+			name: "legal holiday is not counted as overtime",
+			shifts: []string{
+				"2025-06-01 09:00", "2025-06-01 19:00",
+				"2025-06-02 09:00", "2025-06-02 17:00",
+				"2025-06-03 09:00", "2025-06-03 17:00",
+				"2025-06-04 09:00", "2025-06-04 17:00",
+				"2025-06-05 09:00", "2025-06-05 17:00",
+				"2025-06-06 09:00", "2025-06-06 17:00",
+			},
+			want: HoursBreakdown{Regular: 40 * time.Hour, Holiday: 10 * time.Hour},
+		},
+		{
+			name:         "legal holiday on saturday with late night",
+			shifts:       []string{"2025-06-07 18:00", "2025-06-07 23:00"},
+			legalHoliday: time.Saturday,
+			want:         HoursBreakdown{Holiday: 5 * time.Hour, LateNight: time.Hour},
+		},
+		{
+			name: "week spanning the closing day, period ending on friday",
+			shifts: []string{
+				"2025-06-16 09:00", "2025-06-16 17:00",
+				"2025-06-17 09:00", "2025-06-17 17:00",
+				"2025-06-18 09:00", "2025-06-18 17:00",
+				"2025-06-19 09:00", "2025-06-19 17:00",
+				"2025-06-20 09:00", "2025-06-20 17:00",
+				"2025-06-21 09:00", "2025-06-21 17:00",
+			},
+			period: []string{"2025-05-21 00:00", "2025-06-21 00:00"},
+			want:   HoursBreakdown{Regular: 40 * time.Hour},
+		},
+		{
+			name: "week spanning the closing day, period starting on saturday",
+			shifts: []string{
+				"2025-06-16 09:00", "2025-06-16 17:00",
+				"2025-06-17 09:00", "2025-06-17 17:00",
+				"2025-06-18 09:00", "2025-06-18 17:00",
+				"2025-06-19 09:00", "2025-06-19 17:00",
+				"2025-06-20 09:00", "2025-06-20 17:00",
+				"2025-06-21 09:00", "2025-06-21 17:00",
+			},
+			period: []string{"2025-06-21 00:00", "2025-07-21 00:00"},
+			// 前の期間の労働時間は分からないため、期間の最初の週は期間内の労働だけで判定する
+			want: HoursBreakdown{Regular: 8 * time.Hour},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs []AttendanceLog
+			for i := 0; i+1 < len(tt.shifts); i += 2 {
+				logs = append(logs, shiftLogs(t, loc, tt.shifts[i], tt.shifts[i+1])...)
+			}
+			timesheet := BuildTimesheet(logs, loc, WorkplaceSettings{})
+			if tt.period != nil {
+				timesheet = timesheet.Between(mustParseLocal(t, loc, tt.period[0]), mustParseLocal(t, loc, tt.period[1]), loc)
+			}
+
+			pieces := ClassifyHours(timesheet, loc, tt.legalHoliday)
+			got := SummarizeHours(pieces)
+			if got != tt.want {
+				t.Errorf("SummarizeHours() = %+v, want %+v", got, tt.want)
+			}
+			if sum := got.Regular + got.Overtime + got.Holiday; sum != timesheet.Total() {
+				t.Errorf("Regular + Overtime + Holiday = %v, want timesheet total %v", sum, timesheet.Total())
+			}
+		})
+	}
+}
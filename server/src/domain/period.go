@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// MonthRange は loc における year年month月の開始時刻と翌月の開始時刻を返す。
+// 期間は [from, to) の半開区間として扱う。
+func MonthRange(year int, month time.Month, loc *time.Location) (from, to time.Time) {
+	from = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	to = from.AddDate(0, 1, 0)
+	return from, to
+}
+
+// PayrollPeriod は締め日 closingDay で区切った、year年month月に締める給与計算期間を返す。
+// 前月の締め日の翌日0時から当月の締め日の翌日0時までの [from, to) で、closingDay が 0 の場合は月末締め（暦月）になる。
+// 締め日がその月の日数より大きい場合は月末を締め日とする。
+func PayrollPeriod(year int, month time.Month, closingDay int, loc *time.Location) (from, to time.Time) {
+	if closingDay <= 0 {
+		return MonthRange(year, month, loc)
+	}
+	from = closingDate(year, month-1, closingDay, loc).AddDate(0, 0, 1)
+	to = closingDate(year, month, closingDay, loc).AddDate(0, 0, 1)
+	return from, to
+}
+
+// PayrollMonthOf は t（loc の時刻）を含む給与計算期間が何年何月に締める期間かを返す。
+func PayrollMonthOf(t time.Time, closingDay int) (int, time.Month) {
+	y, m, _ := t.Date()
+	if _, to := PayrollPeriod(y, m, closingDay, t.Location()); !t.Before(to) {
+		next := time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+		return next.Year(), next.Month()
+	}
+	return y, m
+}
+
+// closingDate は year年month月の締め日の0時を返す。month は範囲外でも正規化する。
+func closingDate(year int, month time.Month, closingDay int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(closingDay, lastDay), 0, 0, 0, 0, loc)
+}
+
+// ParseYearMonth は "2025", "05" のような年・月の文字列を数値に変換する。
+func ParseYearMonth(year, month string) (int, time.Month, error) {
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return 0, 0, NewError(ErrValidation, "invalid year %q: %v", year, err)
+	}
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return 0, 0, NewError(ErrValidation, "invalid month %q", month)
+	}
+
+	return y, time.Month(m), nil
+}
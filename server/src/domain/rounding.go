@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RoundingDirection は打刻の時刻を丸める方向。
+type RoundingDirection string
+
+const (
+	// RoundingNearest は出勤・退勤・休憩のそれぞれの時刻を最も近い単位に丸める。ちょうど中間の場合は切り上げる。
+	RoundingNearest RoundingDirection = "nearest"
+	// RoundingDown は労働時間が短くなる方向に丸める。出勤と休憩終了は切り上げ、退勤と休憩開始は切り捨てる。
+	RoundingDown RoundingDirection = "down"
+	// RoundingUp は労働時間が長くなる方向に丸める。出勤と休憩終了は切り捨て、退勤と休憩開始は切り上げる。
+	RoundingUp RoundingDirection = "up"
+)
+
+// DefaultRoundingDirection は丸めの方向が設定されていない職場で使う方向。
+const DefaultRoundingDirection = RoundingNearest
+
+// ParseRoundingDirection は設定値を RoundingDirection に変換する。空文字は DefaultRoundingDirection になる。
+func ParseRoundingDirection(value string) (RoundingDirection, error) {
+	switch RoundingDirection(value) {
+	case "":
+		return DefaultRoundingDirection, nil
+	case RoundingNearest, RoundingDown, RoundingUp:
+		return RoundingDirection(value), nil
+	}
+
+	return "", NewError(ErrValidation, "invalid rounding direction %q (nearest, down or up)", value)
+}
+
+// parseRoundingUnit は丸めの単位（分）の設定値を変換する。"off" と "0" は丸めない。
+// 時刻の区切りが毎時同じになるよう、60 を割り切れる分だけを受け付ける。
+func parseRoundingUnit(value string) (int, error) {
+	if strings.ToLower(value) == "off" {
+		return 0, nil
+	}
+	unit, err := strconv.Atoi(value)
+	if err != nil || unit < 0 || unit > 60 || (unit > 0 && 60%unit != 0) {
+		return 0, NewError(ErrValidation, "invalid rounding unit %q (minutes dividing 60, or off)", value)
+	}
+	return unit, nil
+}
+
+// roundSession は勤務の出勤・退勤・休憩の時刻を unit 単位に丸めた勤務を返す。元の勤務は変更しない。
+// 丸めた結果、退勤が出勤より前になる場合は出勤の時刻に、休憩が勤務の外にはみ出す場合は勤務の中に収める。
+func roundSession(session WorkSession, unit time.Duration, direction RoundingDirection, loc *time.Location) WorkSession {
+	if unit <= 0 {
+		return session
+	}
+
+	rounded := session
+	rounded.Start.Timestamp = roundTimestamp(session.Start.Timestamp, unit, direction, true, loc)
+	rounded.End.Timestamp = maxTime(rounded.Start.Timestamp, roundTimestamp(session.End.Timestamp, unit, direction, false, loc))
+	rounded.Breaks = make([]BreakPeriod, len(session.Breaks))
+	for i, b := range session.Breaks {
+		// 休憩開始は労働の終わり、休憩終了は労働の始まりとして丸める
+		start := roundTimestamp(b.Start.Timestamp, unit, direction, false, loc)
+		end := roundTimestamp(b.End.Timestamp, unit, direction, true, loc)
+		start = minTime(maxTime(start, rounded.Start.Timestamp), rounded.End.Timestamp)
+		end = minTime(maxTime(end, start), rounded.End.Timestamp)
+
+		rounded.Breaks[i] = b
+		rounded.Breaks[i].Start.Timestamp = start
+		rounded.Breaks[i].End.Timestamp = end
+	}
+
+	return rounded
+}
+
+// roundTimestamp は t を loc の0時を起点とする unit 単位に丸める。
+// workStarts は t が労働の始まり（出勤・休憩終了）の時刻かどうかで、RoundingDown と RoundingUp の向きを決める。
+func roundTimestamp(t time.Time, unit time.Duration, direction RoundingDirection, workStarts bool, loc *time.Location) time.Time {
+	local := t.In(loc)
+	y, m, d := local.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	floor := midnight.Add(local.Sub(midnight) / unit * unit)
+	if floor.Equal(local) {
+		return floor
+	}
+	ceil := floor.Add(unit)
+
+	switch direction {
+	case RoundingDown:
+		if workStarts {
+			return ceil
+		}
+		return floor
+	case RoundingUp:
+		if workStarts {
+			return floor
+		}
+		return ceil
+	}
+	if local.Sub(floor) < ceil.Sub(local) {
+		return floor
+	}
+	return ceil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
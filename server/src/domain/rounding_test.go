@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkplaceSettingsSetRounding(t *testing.T) {
+	tests := []struct {
+		key, value    string
+		wantUnit      int
+		wantDirection RoundingDirection
+		wantErr       bool
+	}{
+		{key: "rounding_unit", value: "15", wantUnit: 15},
+		{key: "rounding_unit", value: "60", wantUnit: 60},
+		{key: "rounding_unit", value: "off"},
+		{key: "rounding_unit", value: "0"},
+		{key: "rounding_unit", value: "7", wantErr: true},
+		{key: "rounding_unit", value: "90", wantErr: true},
+		{key: "rounding_unit", value: "-15", wantErr: true},
+		{key: "rounding_direction", value: "down", wantDirection: RoundingDown},
+		{key: "rounding_direction", value: "up", wantDirection: RoundingUp},
+		{key: "rounding_direction", value: "nearest", wantDirection: RoundingNearest},
+		{key: "rounding_direction", value: "sideways", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			var settings WorkplaceSettings
+			err := settings.Set(tt.key, tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("Set() error = %v, want ErrValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if settings.RoundingUnit != tt.wantUnit || settings.RoundingDirection != tt.wantDirection {
+				t.Errorf("rounding = %d / %q, want %d / %q", settings.RoundingUnit, settings.RoundingDirection, tt.wantUnit, tt.wantDirection)
+			}
+		})
+	}
+}
+
+func TestBuildTimesheetRounding(t *testing.T) {
+	loc := mustLoadLocation(t, "Asia/Tokyo")
+	shift := shiftLogs(t, loc, "2025-06-02 09:07", "2025-06-02 17:53", "2025-06-02 12:02", "2025-06-02 12:58")
+
+	tests := []struct {
+		name      string
+		logs      []AttendanceLog
+		loc       *time.Location
+		settings  WorkplaceSettings
+		wantTotal time.Duration
+		wantBreak time.Duration
+	}{
+		{
+			name:      "off",
+			logs:      shift,
+			wantTotal: 7*time.Hour + 50*time.Minute,
+			wantBreak: 56 * time.Minute,
+		},
+		{
+			// 09:00 - 18:00、休憩 12:00 - 13:00
+			name:      "nearest",
+			logs:      shift,
+			settings:  WorkplaceSettings{RoundingUnit: 15},
+			wantTotal: 8 * time.Hour,
+			wantBreak: time.Hour,
+		},
+		{
+			// 09:15 - 17:45、休憩 12:00 - 13:00
+			name:      "down",
+			logs:      shift,
+			settings:  WorkplaceSettings{RoundingUnit: 15, RoundingDirection: RoundingDown},
+			wantTotal: 7*time.Hour + 30*time.Minute,
+			wantBreak: time.Hour,
+		},
+		{
+			// 09:00 - 18:00、休憩 12:15 - 12:45
+			name:      "up",
+			logs:      shift,
+			settings:  WorkplaceSettings{RoundingUnit: 15, RoundingDirection: RoundingUp},
+			wantTotal: 8*time.Hour + 30*time.Minute,
+			wantBreak: 30 * time.Minute,
+		},
+		{
+			name:     "shift shorter than the unit rounds to zero instead of negative",
+			logs:     shiftLogs(t, loc, "2025-06-02 09:05", "2025-06-02 09:10"),
+			settings: WorkplaceSettings{RoundingUnit: 15, RoundingDirection: RoundingDown},
+		},
+		{
+			// UTC からのずれが30分のタイムゾーンでも、職場の時刻で丸める
+			name:      "workplace timezone with a half-hour offset",
+			logs:      shiftLogs(t, mustLoadLocation(t, "Asia/Kolkata"), "2025-06-02 09:20", "2025-06-02 17:40"),
+			loc:       mustLoadLocation(t, "Asia/Kolkata"),
+			settings:  WorkplaceSettings{RoundingUnit: 60},
+			wantTotal: 9 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsLoc := loc
+			if tt.loc != nil {
+				tsLoc = tt.loc
+			}
+			timesheet := BuildTimesheet(tt.logs, tsLoc, tt.settings)
+			if got := timesheet.Total(); got != tt.wantTotal {
+				t.Errorf("Total() = %v, want %v", got, tt.wantTotal)
+			}
+			if got := timesheet.BreakTotal(); got != tt.wantBreak {
+				t.Errorf("BreakTotal() = %v, want %v", got, tt.wantBreak)
+			}
+		})
+	}
+
+	// 丸めは集計にだけ使い、記録した時刻は変えない
+	if want := mustParseLocal(t, loc, "2025-06-02 09:07"); !shift[0].Timestamp.Equal(want) {
+		t.Errorf("the original log was changed to %v", shift[0].Timestamp)
+	}
+}
+
+func TestEstimatePayRounding(t *testing.T) {
+	loc := mustLoadLocation(t, "Asia/Tokyo")
+	settings := WorkplaceSettings{
+		RoundingUnit:      15,
+		RoundingDirection: RoundingDown,
+		HourlyWages:       []HourlyWage{{EffectiveFrom: "2025-01-01", Amount: 1000}},
+	}
+
+	// 09:15 - 17:45 に丸めた 8時間30分のうち、30分が時間外労働
+	timesheet := BuildTimesheet(shiftLogs(t, loc, "2025-06-02 09:07", "2025-06-02 17:53"), loc, settings)
+	estimate := EstimatePay(ClassifyHours(timesheet, loc, settings.LegalHolidayWeekday()), settings)
+	if estimate.GrossPay != 8625 || estimate.PremiumPay != 125 {
+		t.Errorf("EstimatePay() = %d (premium %d), want 8625 (premium 125)", estimate.GrossPay, estimate.PremiumPay)
+	}
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// SlackToken は OAuth で取得した Slack のトークン。ブラウザには返さずサーバー側でのみ保管する。
+type SlackToken struct {
+	TeamID    string
+	UserID    string
+	BotToken  string
+	UserToken string
+	Scope     string
+	UpdatedAt time.Time
+}
+
+// SlackTokenID は team と user からトークンを保管するキーを作る。
+func SlackTokenID(teamID, userID string) string {
+	return fmt.Sprintf("%s#%s", teamID, userID)
+}
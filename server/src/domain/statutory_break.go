@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// RequiredBreak は労働基準法第34条で、労働時間 work に対して与えなければならない休憩時間を返す。
+// 6時間を超える場合は45分、8時間を超える場合は60分。
+func RequiredBreak(work time.Duration) time.Duration {
+	switch {
+	case work > 8*time.Hour:
+		return 60 * time.Minute
+	case work > 6*time.Hour:
+		return 45 * time.Minute
+	}
+	return 0
+}
+
+// StatutoryBreakDeduction は勤務の拘束時間 gross と記録された休憩 recorded から、
+// 法定の休憩に足りない分として自動で差し引く時間を返す。休憩を十分に記録している場合は 0。
+func StatutoryBreakDeduction(gross, recorded time.Duration) time.Duration {
+	required := RequiredBreak(gross - recorded)
+	if recorded >= required {
+		return 0
+	}
+	return required - recorded
+}
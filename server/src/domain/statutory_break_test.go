@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequiredBreak(t *testing.T) {
+	tests := []struct {
+		name string
+		work time.Duration
+		want time.Duration
+	}{
+		{name: "no work", work: 0, want: 0},
+		{name: "exactly 6h", work: 6 * time.Hour, want: 0},
+		{name: "just over 6h", work: 6*time.Hour + time.Minute, want: 45 * time.Minute},
+		{name: "exactly 8h", work: 8 * time.Hour, want: 45 * time.Minute},
+		{name: "just over 8h", work: 8*time.Hour + time.Minute, want: 60 * time.Minute},
+		{name: "long shift", work: 12 * time.Hour, want: 60 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiredBreak(tt.work); got != tt.want {
+				t.Errorf("RequiredBreak(%v) = %v, want %v", tt.work, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatutoryBreakDeduction(t *testing.T) {
+	tests := []struct {
+		name     string
+		gross    time.Duration
+		recorded time.Duration
+		want     time.Duration
+	}{
+		{name: "6h without break", gross: 6 * time.Hour, want: 0},
+		{name: "over 6h without break", gross: 6*time.Hour + 30*time.Minute, want: 45 * time.Minute},
+		{name: "over 6h with short break", gross: 7 * time.Hour, recorded: 30 * time.Minute, want: 15 * time.Minute},
+		{name: "over 6h with enough break", gross: 7 * time.Hour, recorded: 45 * time.Minute, want: 0},
+		{name: "break brings work to exactly 6h", gross: 6*time.Hour + 45*time.Minute, recorded: 45 * time.Minute, want: 0},
+		{name: "exactly 8h without break", gross: 8 * time.Hour, want: 45 * time.Minute},
+		{name: "over 8h without break", gross: 9 * time.Hour, want: 60 * time.Minute},
+		{name: "over 8h with 45m break", gross: 9 * time.Hour, recorded: 45 * time.Minute, want: 15 * time.Minute},
+		{name: "break brings work to exactly 8h", gross: 8*time.Hour + 45*time.Minute, recorded: 45 * time.Minute, want: 0},
+		{name: "over 8h with enough break", gross: 10 * time.Hour, recorded: 90 * time.Minute, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatutoryBreakDeduction(tt.gross, tt.recorded); got != tt.want {
+				t.Errorf("StatutoryBreakDeduction(%v, %v) = %v, want %v", tt.gross, tt.recorded, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTimesheetStatutoryBreak(t *testing.T) {
+	loc := mustLoadLocation(t, "Asia/Tokyo")
+
+	tests := []struct {
+		name      string
+		logs      []AttendanceLog
+		settings  WorkplaceSettings
+		wantTotal time.Duration
+		wantBreak time.Duration
+		wantDays  map[string]time.Duration
+	}{
+		{
+			name:      "disabled",
+			logs:      shiftLogs(t, loc, "2025-06-02 09:00", "2025-06-02 18:00"),
+			wantTotal: 9 * time.Hour,
+			wantDays:  map[string]time.Duration{"2025-06-02": 9 * time.Hour},
+		},
+		{
+			name:      "9h without break",
+			logs:      shiftLogs(t, loc, "2025-06-02 09:00", "2025-06-02 18:00"),
+			settings:  WorkplaceSettings{StatutoryBreak: true},
+			wantTotal: 8 * time.Hour,
+			wantBreak: 60 * time.Minute,
+			wantDays:  map[string]time.Duration{"2025-06-02": 8 * time.Hour},
+		},
+		{
+			name:      "9h with recorded 30m break",
+			logs:      shiftLogs(t, loc, "2025-06-02 09:00", "2025-06-02 18:00", "2025-06-02 12:00", "2025-06-02 12:30"),
+			settings:  WorkplaceSettings{StatutoryBreak: true},
+			wantTotal: 8 * time.Hour,
+			wantBreak: 30 * time.Minute,
+			wantDays:  map[string]time.Duration{"2025-06-02": 8 * time.Hour},
+		},
+		{
+			name:      "7h with enough recorded break",
+			logs:      shiftLogs(t, loc, "2025-06-02 09:00", "2025-06-02 16:00", "2025-06-02 12:00", "2025-06-02 12:45"),
+			settings:  WorkplaceSettings{StatutoryBreak: true},
+			wantTotal: 6*time.Hour + 15*time.Minute,
+			wantDays:  map[string]time.Duration{"2025-06-02": 6*time.Hour + 15*time.Minute},
+		},
+		{
+			name:      "deduction is taken from the first day when split at midnight",
+			logs:      shiftLogs(t, loc, "2025-06-02 20:00", "2025-06-03 05:00"),
+			settings:  WorkplaceSettings{AttributionRule: AttributionSplitMidnight, StatutoryBreak: true},
+			wantTotal: 8 * time.Hour,
+			wantBreak: 60 * time.Minute,
+			wantDays:  map[string]time.Duration{"2025-06-02": 3 * time.Hour, "2025-06-03": 5 * time.Hour},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timesheet := BuildTimesheet(tt.logs, loc, tt.settings)
+			if got := timesheet.Total(); got != tt.wantTotal {
+				t.Errorf("Total() = %v, want %v", got, tt.wantTotal)
+			}
+			if got := timesheet.StatutoryBreakTotal(); got != tt.wantBreak {
+				t.Errorf("StatutoryBreakTotal() = %v, want %v", got, tt.wantBreak)
+			}
+			if len(timesheet.Days) != len(tt.wantDays) {
+				t.Fatalf("len(Days) = %d, want %d", len(timesheet.Days), len(tt.wantDays))
+			}
+			for _, day := range timesheet.Days {
+				if got := day.Total(); got != tt.wantDays[day.Date] {
+					t.Errorf("Days[%s].Total() = %v, want %v", day.Date, got, tt.wantDays[day.Date])
+				}
+			}
+		})
+	}
+}
+
+// shiftLogs は loc での時刻 (YYYY-MM-DD HH:MM) から、出勤・退勤と休憩開始・休憩終了の組の記録を作る。
+func shiftLogs(t *testing.T, loc *time.Location, start, end string, breaks ...string) []AttendanceLog {
+	t.Helper()
+	logs := []AttendanceLog{
+		{Action: ActionStart, Timestamp: mustParseLocal(t, loc, start)},
+		{Action: ActionEnd, Timestamp: mustParseLocal(t, loc, end)},
+	}
+	for i := 0; i+1 < len(breaks); i += 2 {
+		logs = append(logs,
+			AttendanceLog{Action: ActionBreakStart, Timestamp: mustParseLocal(t, loc, breaks[i])},
+			AttendanceLog{Action: ActionBreakEnd, Timestamp: mustParseLocal(t, loc, breaks[i+1])},
+		)
+	}
+	return logs
+}
+
+func mustParseLocal(t *testing.T, loc *time.Location, value string) time.Time {
+	t.Helper()
+	ts, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", value, err)
+	}
+	return ts
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load location %q: %v", name, err)
+	}
+	return loc
+}
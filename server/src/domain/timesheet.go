@@ -0,0 +1,373 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// AttributionRule は日付をまたぐ勤務をどの日に計上するかのルール。
+type AttributionRule string
+
+const (
+	// AttributionStartDay は勤務全体を出勤した日に計上する。
+	AttributionStartDay AttributionRule = "start_day"
+	// AttributionSplitMidnight は勤務を0時で分割し、それぞれの日に計上する。
+	AttributionSplitMidnight AttributionRule = "split_midnight"
+)
+
+// DefaultAttributionRule は設定が無い職場で使うルール。
+const DefaultAttributionRule = AttributionStartDay
+
+// ParseAttributionRule は設定値を AttributionRule に変換する。空文字は DefaultAttributionRule になる。
+func ParseAttributionRule(value string) (AttributionRule, error) {
+	switch AttributionRule(value) {
+	case "":
+		return DefaultAttributionRule, nil
+	case AttributionStartDay, AttributionSplitMidnight:
+		return AttributionRule(value), nil
+	}
+
+	return "", NewError(ErrValidation, "invalid attribution rule %q (start_day or split_midnight)", value)
+}
+
+// BreakPeriod は勤務中の1回の休憩。
+type BreakPeriod struct {
+	Start AttendanceLog
+	End   AttendanceLog
+}
+
+// WorkSession は出勤から退勤までの1回の勤務。
+type WorkSession struct {
+	Start  AttendanceLog
+	End    AttendanceLog
+	Breaks []BreakPeriod
+}
+
+// Duration は休憩を含む拘束時間を返す。
+func (s WorkSession) Duration() time.Duration {
+	return s.End.Timestamp.Sub(s.Start.Timestamp)
+}
+
+// BreakDuration は記録された休憩時間の合計を返す。
+func (s WorkSession) BreakDuration() time.Duration {
+	var total time.Duration
+	for _, b := range s.Breaks {
+		total += b.End.Timestamp.Sub(b.Start.Timestamp)
+	}
+	return total
+}
+
+// UnmatchedReason は対になる記録が見つからなかった理由。
+type UnmatchedReason string
+
+const (
+	// UnmatchedMissingEnd は出勤の後に退勤が無い（次の記録も出勤だった、または最後の記録が出勤）。
+	UnmatchedMissingEnd UnmatchedReason = "missing_end"
+	// UnmatchedMissingStart は退勤の前に出勤が無い。
+	UnmatchedMissingStart UnmatchedReason = "missing_start"
+	// UnmatchedMissingBreakEnd は休憩開始の後に休憩終了が無い。この休憩は差し引かない。
+	UnmatchedMissingBreakEnd UnmatchedReason = "missing_break_end"
+	// UnmatchedMissingBreakStart は休憩終了の前に休憩開始が無い。
+	UnmatchedMissingBreakStart UnmatchedReason = "missing_break_start"
+	// UnmatchedOutsideShift は勤務として成立していない区間の休憩の記録。
+	UnmatchedOutsideShift UnmatchedReason = "outside_shift"
+)
+
+// UnmatchedEvent は勤務として組にできなかった記録。集計には含めない。
+type UnmatchedEvent struct {
+	Log    AttendanceLog
+	Reason UnmatchedReason
+}
+
+// sessionBuilder は PairSessions で組み立て中の勤務。
+type sessionBuilder struct {
+	start      AttendanceLog
+	breaks     []BreakPeriod
+	breakStart *AttendanceLog
+	// breakLogs は組にできた休憩の記録。勤務が成立しなかった場合に unmatched として返す
+	breakLogs []AttendanceLog
+}
+
+// discard は勤務が成立しなかったときに、その勤務に含まれていた記録を unmatched として返す。
+func (b *sessionBuilder) discard() []UnmatchedEvent {
+	events := []UnmatchedEvent{{Log: b.start, Reason: UnmatchedMissingEnd}}
+	for _, log := range b.breakLogs {
+		events = append(events, UnmatchedEvent{Log: log, Reason: UnmatchedOutsideShift})
+	}
+	if b.breakStart != nil {
+		events = append(events, UnmatchedEvent{Log: *b.breakStart, Reason: UnmatchedOutsideShift})
+	}
+	return events
+}
+
+// PairSessions は記録を時刻順に並べ、出勤と次の退勤を日付に関係なく1回の勤務として組にする。
+// 勤務中の休憩開始と休憩終了も組にして勤務に含める。
+// 組にできなかった記録は捨てずに unmatched として返す。
+func PairSessions(logs []AttendanceLog) (sessions []WorkSession, unmatched []UnmatchedEvent) {
+	sorted := make([]AttendanceLog, len(logs))
+	copy(sorted, logs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var open *sessionBuilder
+	for i := range sorted {
+		log := sorted[i]
+		switch log.Action {
+		case ActionStart:
+			if open != nil {
+				unmatched = append(unmatched, open.discard()...)
+			}
+			open = &sessionBuilder{start: log}
+		case ActionBreakStart:
+			if open == nil {
+				unmatched = append(unmatched, UnmatchedEvent{Log: log, Reason: UnmatchedOutsideShift})
+				continue
+			}
+			if open.breakStart != nil {
+				unmatched = append(unmatched, UnmatchedEvent{Log: *open.breakStart, Reason: UnmatchedMissingBreakEnd})
+			}
+			open.breakStart = &log
+		case ActionBreakEnd:
+			if open == nil {
+				unmatched = append(unmatched, UnmatchedEvent{Log: log, Reason: UnmatchedOutsideShift})
+				continue
+			}
+			if open.breakStart == nil {
+				unmatched = append(unmatched, UnmatchedEvent{Log: log, Reason: UnmatchedMissingBreakStart})
+				continue
+			}
+			open.breaks = append(open.breaks, BreakPeriod{Start: *open.breakStart, End: log})
+			open.breakLogs = append(open.breakLogs, *open.breakStart, log)
+			open.breakStart = nil
+		case ActionEnd:
+			if open == nil {
+				unmatched = append(unmatched, UnmatchedEvent{Log: log, Reason: UnmatchedMissingStart})
+				continue
+			}
+			if open.breakStart != nil {
+				unmatched = append(unmatched, UnmatchedEvent{Log: *open.breakStart, Reason: UnmatchedMissingBreakEnd})
+			}
+			sessions = append(sessions, WorkSession{Start: open.start, End: log, Breaks: open.breaks})
+			open = nil
+		}
+	}
+	if open != nil {
+		unmatched = append(unmatched, open.discard()...)
+	}
+
+	return sessions, unmatched
+}
+
+// WorkSegment は勤務のうち、ある1日に計上する区間。
+type WorkSegment struct {
+	Session WorkSession
+	Start   time.Time
+	End     time.Time
+	// StatutoryBreak はこの区間から自動で差し引いた法定休憩の時間
+	StatutoryBreak time.Duration
+}
+
+// GrossDuration は休憩を含む区間の長さを返す。
+func (s WorkSegment) GrossDuration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// BreakDuration は区間に含まれる休憩の長さを返す。0時で分割した場合はこの区間に重なる部分だけを数える。
+func (s WorkSegment) BreakDuration() time.Duration {
+	var total time.Duration
+	for _, b := range s.Session.Breaks {
+		start, end := b.Start.Timestamp, b.End.Timestamp
+		if start.Before(s.Start) {
+			start = s.Start
+		}
+		if end.After(s.End) {
+			end = s.End
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// WorkedDuration は記録された休憩を除き、法定休憩の自動控除を行う前の労働時間を返す。
+func (s WorkSegment) WorkedDuration() time.Duration {
+	return s.GrossDuration() - s.BreakDuration()
+}
+
+// Duration は休憩と法定休憩の自動控除を除いた実働時間を返す。
+func (s WorkSegment) Duration() time.Duration {
+	return s.WorkedDuration() - s.StatutoryBreak
+}
+
+// DailyWork は1日分の勤務。
+type DailyWork struct {
+	Date     string // loc での日付 (YYYY-MM-DD)
+	Segments []WorkSegment
+}
+
+// Total は休憩を除いた1日の実働時間を返す。
+func (d DailyWork) Total() time.Duration {
+	var total time.Duration
+	for _, s := range d.Segments {
+		total += s.Duration()
+	}
+	return total
+}
+
+// BreakTotal は1日の休憩時間を返す。
+func (d DailyWork) BreakTotal() time.Duration {
+	var total time.Duration
+	for _, s := range d.Segments {
+		total += s.BreakDuration()
+	}
+	return total
+}
+
+// WorkedTotal は法定休憩の自動控除を行う前の1日の労働時間を返す。
+func (d DailyWork) WorkedTotal() time.Duration {
+	var total time.Duration
+	for _, s := range d.Segments {
+		total += s.WorkedDuration()
+	}
+	return total
+}
+
+// StatutoryBreakTotal は1日に自動で差し引いた法定休憩の時間を返す。
+func (d DailyWork) StatutoryBreakTotal() time.Duration {
+	var total time.Duration
+	for _, s := range d.Segments {
+		total += s.StatutoryBreak
+	}
+	return total
+}
+
+// Timesheet は勤怠記録を日ごとに集計した結果。
+type Timesheet struct {
+	Days      []DailyWork
+	Unmatched []UnmatchedEvent
+}
+
+// Total は休憩を除いた実働時間の合計を返す。
+func (t Timesheet) Total() time.Duration {
+	var total time.Duration
+	for _, d := range t.Days {
+		total += d.Total()
+	}
+	return total
+}
+
+// BreakTotal は休憩時間の合計を返す。
+func (t Timesheet) BreakTotal() time.Duration {
+	var total time.Duration
+	for _, d := range t.Days {
+		total += d.BreakTotal()
+	}
+	return total
+}
+
+// WorkedTotal は法定休憩の自動控除を行う前の労働時間の合計を返す。
+func (t Timesheet) WorkedTotal() time.Duration {
+	var total time.Duration
+	for _, d := range t.Days {
+		total += d.WorkedTotal()
+	}
+	return total
+}
+
+// StatutoryBreakTotal は自動で差し引いた法定休憩の合計を返す。
+func (t Timesheet) StatutoryBreakTotal() time.Duration {
+	var total time.Duration
+	for _, d := range t.Days {
+		total += d.StatutoryBreakTotal()
+	}
+	return total
+}
+
+// Between は loc の日付で [from, to) に計上された日と、その期間の unmatched だけを残した Timesheet を返す。
+// 期間の境界をまたぐ勤務を組にするため、期間より広く取得した記録から作った Timesheet を期間に絞り込むのに使う。
+func (t Timesheet) Between(from, to time.Time, loc *time.Location) Timesheet {
+	fromDate, toDate := from.In(loc).Format("2006-01-02"), to.In(loc).Format("2006-01-02")
+	var result Timesheet
+	for _, d := range t.Days {
+		if d.Date >= fromDate && d.Date < toDate {
+			result.Days = append(result.Days, d)
+		}
+	}
+	for _, e := range t.Unmatched {
+		if !e.Log.Timestamp.Before(from) && e.Log.Timestamp.Before(to) {
+			result.Unmatched = append(result.Unmatched, e)
+		}
+	}
+	return result
+}
+
+// BuildTimesheet は記録を勤務の組にし、職場の設定に従って loc の日付ごとに計上する。
+// 打刻の丸めが設定されている場合は、丸めた時刻で計上する。
+func BuildTimesheet(logs []AttendanceLog, loc *time.Location, settings WorkplaceSettings) Timesheet {
+	sessions, unmatched := PairSessions(logs)
+	unit, direction := settings.Rounding()
+
+	byDate := make(map[string][]WorkSegment)
+	for _, session := range sessions {
+		session = roundSession(session, unit, direction, loc)
+		segments := attributeSession(session, settings.Attribution(), loc)
+		if settings.StatutoryBreak {
+			deductStatutoryBreak(segments, StatutoryBreakDeduction(session.Duration(), session.BreakDuration()))
+		}
+		for _, segment := range segments {
+			date := segment.Start.In(loc).Format("2006-01-02")
+			byDate[date] = append(byDate[date], segment)
+		}
+	}
+
+	dates := make([]string, 0, len(byDate))
+	for d := range byDate {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+
+	days := make([]DailyWork, 0, len(dates))
+	for _, d := range dates {
+		days = append(days, DailyWork{Date: d, Segments: byDate[d]})
+	}
+
+	return Timesheet{Days: days, Unmatched: unmatched}
+}
+
+// deductStatutoryBreak は1回の勤務で差し引く法定休憩 deduction を、先頭の区間から順に割り当てる。
+func deductStatutoryBreak(segments []WorkSegment, deduction time.Duration) {
+	for i := range segments {
+		if deduction <= 0 {
+			return
+		}
+		d := min(deduction, segments[i].WorkedDuration())
+		segments[i].StatutoryBreak = d
+		deduction -= d
+	}
+}
+
+// attributeSession は1回の勤務を rule に従って日ごとの区間に分ける。
+func attributeSession(session WorkSession, rule AttributionRule, loc *time.Location) []WorkSegment {
+	start := session.Start.Timestamp.In(loc)
+	end := session.End.Timestamp.In(loc)
+	if rule != AttributionSplitMidnight || !start.Before(end) {
+		return []WorkSegment{{Session: session, Start: start, End: end}}
+	}
+
+	var segments []WorkSegment
+	for start.Before(end) {
+		// 夏時間の切り替わりがあっても正しい0時になるよう、日付から翌日0時を求める
+		y, m, d := start.Date()
+		midnight := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		segmentEnd := end
+		if midnight.Before(end) {
+			segmentEnd = midnight
+		}
+		segments = append(segments, WorkSegment{Session: session, Start: start, End: segmentEnd})
+		start = segmentEnd
+	}
+
+	return segments
+}
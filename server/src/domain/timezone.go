@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+)
+
+// DefaultTimezone はタイムゾーンが未設定の職場（既存データを含む）で使うタイムゾーン。
+const DefaultTimezone = "Asia/Tokyo"
+
+// LoadTimezone は IANA タイムゾーン名を検証して time.Location を返す。
+// 空文字の場合は DefaultTimezone を使う。
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimezone
+	}
+	// "Local" はサーバーの環境に依存するため受け付けない
+	if name == "Local" {
+		return nil, NewError(ErrValidation, "invalid timezone %q", name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, NewError(ErrValidation, "invalid timezone %q: %v", name, err)
+	}
+
+	return loc, nil
+}
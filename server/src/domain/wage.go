@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// HourlyWage は職場の時給。EffectiveFrom 以降、次の時給が適用されるまでの勤務に使う。
+type HourlyWage struct {
+	EffectiveFrom string `dynamodbav:"effective_from"` // 職場のタイムゾーンでの適用開始日 (YYYY-MM-DD)
+	Amount        int64  `dynamodbav:"amount"`         // 円
+}
+
+// WageOn は date (YYYY-MM-DD) に適用される時給を返す。該当する時給が無い場合は false を返す。
+// wages は EffectiveFrom の昇順に並んでいること。
+func WageOn(wages []HourlyWage, date string) (HourlyWage, bool) {
+	var found HourlyWage
+	ok := false
+	for _, w := range wages {
+		if w.EffectiveFrom > date {
+			break
+		}
+		found, ok = w, true
+	}
+	return found, ok
+}
+
+// SetHourlyWage は effectiveFrom から適用する時給を登録する。同じ日付の時給があれば置き換える。
+func (s *WorkplaceSettings) SetHourlyWage(amount int64, effectiveFrom string) error {
+	if amount <= 0 {
+		return NewError(ErrValidation, "invalid hourly wage %d", amount)
+	}
+	if _, err := time.Parse("2006-01-02", effectiveFrom); err != nil {
+		return NewError(ErrValidation, "invalid effective date %q (YYYY-MM-DD)", effectiveFrom)
+	}
+
+	wages := make([]HourlyWage, 0, len(s.HourlyWages)+1)
+	for _, w := range s.HourlyWages {
+		if w.EffectiveFrom != effectiveFrom {
+			wages = append(wages, w)
+		}
+	}
+	wages = append(wages, HourlyWage{EffectiveFrom: effectiveFrom, Amount: amount})
+	sort.Slice(wages, func(i, j int) bool {
+		return wages[i].EffectiveFrom < wages[j].EffectiveFrom
+	})
+	s.HourlyWages = wages
+
+	return nil
+}
+
+// PayEstimate は勤務時間と時給から見積もった支給額（額面）。
+type PayEstimate struct {
+	GrossPay int64 // 円。割増分を含む。1円未満は四捨五入する
+	// PremiumPay は GrossPay のうち時間外・深夜・休日の割増分
+	PremiumPay int64
+	// UnpricedDuration は時給が設定されていない日の実働時間。GrossPay には含まれない
+	UnpricedDuration time.Duration
+}
+
+// EstimatePay は ClassifyHours で区分した労働時間に、各日付に適用される時給と割増率を掛けて支給額を見積もる。
+// 割増は職場の設定で有効なものだけを加算し、深夜の割増は時間外・休日の割増と重ねて加算する。
+// 打刻の丸めは BuildTimesheet で済んでいるため、pieces の時間をそのまま使う。
+func EstimatePay(pieces []WorkPiece, settings WorkplaceSettings) PayEstimate {
+	var estimate PayEstimate
+	// 端数が積み重ならないよう、円×秒×% で合計してから最後に時間単位へ換算する
+	var base, premium int64
+	for _, p := range pieces {
+		wage, ok := WageOn(settings.HourlyWages, p.Date)
+		if !ok {
+			estimate.UnpricedDuration += p.Duration()
+			continue
+		}
+		amount := int64(p.Duration()/time.Second) * wage.Amount
+		base += amount * 100
+		premium += amount * premiumRate(p, settings)
+	}
+	estimate.GrossPay = (base + premium + 180000) / 360000
+	estimate.PremiumPay = estimate.GrossPay - (base+180000)/360000
+
+	return estimate
+}
+
+// premiumRate は p に適用する割増率（%）の合計を返す。
+func premiumRate(p WorkPiece, settings WorkplaceSettings) int64 {
+	var rate int64
+	if p.Overtime && settings.OvertimePremiumEnabled() {
+		rate += OvertimePremiumRate
+	}
+	if p.Holiday && settings.HolidayPremiumEnabled() {
+		rate += HolidayPremiumRate
+	}
+	if p.LateNight && settings.LateNightPremiumEnabled() {
+		rate += LateNightPremiumRate
+	}
+	return rate
+}
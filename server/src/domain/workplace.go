@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Workplace は職場。名前と設定を持ち、ユーザーはチャンネルごとの WorkplaceBindings で職場を登録する。
+// 勤怠記録の workplace_id はこの ID を指す。
+type Workplace struct {
+	ID     string `dynamodbav:"id"`
+	TeamID string `dynamodbav:"team_id"`
+	Name   string `dynamodbav:"name"`
+	// Shared が true の職場はチームの職場。管理者が一度作り、メンバーはそれぞれのチャンネルから参加する。
+	// false の職場は /subscribe-workplace で作った個人の職場で、登録したユーザーだけが使う
+	Shared bool `dynamodbav:"shared"`
+	// CreatedBy は職場を作ったユーザー。チームの職場ではこのユーザーが管理者になる
+	CreatedBy string `dynamodbav:"created_by"`
+	// Roles はユーザーごとの役割。含まれないユーザーの役割は RoleOf を参照
+	Roles map[string]WorkplaceRole `dynamodbav:"roles"`
+	WorkplaceSettings
+	CreatedAt time.Time `dynamodbav:"created_at"`
+	UpdatedAt time.Time `dynamodbav:"updated_at"`
+}
+
+// NewWorkplace は team の職場 name を作る。createdBy は職場を作ったユーザー。
+func NewWorkplace(id, teamID, createdBy, name string, settings WorkplaceSettings, createdAt time.Time) Workplace {
+	return Workplace{
+		ID:                id,
+		TeamID:            teamID,
+		Name:              name,
+		CreatedBy:         createdBy,
+		WorkplaceSettings: settings,
+		CreatedAt:         createdAt,
+		UpdatedAt:         createdAt,
+	}
+}
+
+// RoleOf は userID の職場での役割を返す。Roles に無い場合、職場を作ったユーザーは admin、それ以外は member になる。
+// 個人の職場は登録したユーザーしか参照できないので、常に admin とする。
+func (w *Workplace) RoleOf(userID string) WorkplaceRole {
+	if !w.Shared {
+		return RoleAdmin
+	}
+	if role, ok := w.Roles[userID]; ok {
+		return role
+	}
+	if w.CreatedBy == userID {
+		return RoleAdmin
+	}
+	return RoleMember
+}
+
+// Authorize は userID が職場で required 以上の役割を持っているか確認する。持っていない場合は ErrForbidden を返す。
+func (w *Workplace) Authorize(userID string, required WorkplaceRole) error {
+	if !w.RoleOf(userID).Includes(required) {
+		return NewError(ErrForbidden, "%s role is required in workplace %q", required, w.Name)
+	}
+	return nil
+}
+
+// FindWorkplaceByName は workplaces から職場名が name の職場を返す。見つからない場合は ErrNotFound を返す。
+func FindWorkplaceByName(workplaces []Workplace, name string) (*Workplace, error) {
+	name = strings.TrimSpace(name)
+	for i := range workplaces {
+		if workplaces[i].Name == name {
+			return &workplaces[i], nil
+		}
+	}
+
+	return nil, NewError(ErrNotFound, "workplace %q not found", name)
+}
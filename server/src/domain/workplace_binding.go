@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetWorkplace は登録した職場の名前と設定を反映する。
+func (b *WorkplaceBindings) SetWorkplace(w *Workplace) {
+	b.WorkplaceID = w.ID
+	b.Workplace = w.Name
+	b.WorkplaceSettings = w.WorkplaceSettings
+}
+
+// IsOwnedBy は職場の登録が指定した team / channel / user のものかを返す。
+func (b *WorkplaceBindings) IsOwnedBy(teamID, channelID, userID string) bool {
+	return b.TeamId == teamID && b.CannelId == channelID && b.UserId == userID
+}
+
+// OnShift は最後に記録した action から、勤務中（休憩中を含む）かを返す。
+// last_action が無い既存の職場は勤務中として扱わない。
+func (b *WorkplaceBindings) OnShift() bool {
+	return b.LastAction != "" && b.LastAction != ActionEnd
+}
+
+// IsArchived は職場の登録が解除されている（論理削除されている）かを返す。
+// 登録を解除した職場でも、勤怠記録はその職場の ID で参照できる。
+func (b *WorkplaceBindings) IsArchived() bool {
+	return b.DeletedAt != nil
+}
+
+// ValidateWorkplaceName は職場名の前後の空白を除き、空でないか確認する。
+func ValidateWorkplaceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", NewError(ErrValidation, "workplace name is required")
+	}
+	return name, nil
+}
+
+// AmbiguousWorkplaceError は職場名を省略したが、候補の職場が複数ある場合のエラー。
+// Candidates は選択肢として示す職場名。
+type AmbiguousWorkplaceError struct {
+	Candidates []string
+}
+
+func (e *AmbiguousWorkplaceError) Error() string {
+	return fmt.Sprintf("multiple workplaces are subscribed in this channel; specify one of: %s", strings.Join(e.Candidates, ", "))
+}
+
+func (e *AmbiguousWorkplaceError) Is(target error) bool {
+	return target == ErrAmbiguousWorkplace
+}
+
+// SelectWorkplaceBinding は同じチャンネルに登録された職場 bindings から、操作の対象を選ぶ。
+// name を指定した場合はその名前の職場を選ぶ。省略した場合は、職場が1つならそれを、
+// 複数あれば prefer（nil 可）を満たす職場が1つだけのときにそれを選ぶ。
+// 選べない場合、職場が無ければ ErrNotSubscribed、候補が複数なら AmbiguousWorkplaceError を返す。
+func SelectWorkplaceBinding(bindings []WorkplaceBindings, name string, prefer func(*WorkplaceBindings) bool) (*WorkplaceBindings, error) {
+	name = strings.TrimSpace(name)
+	if name != "" {
+		for i := range bindings {
+			if bindings[i].Workplace == name {
+				return &bindings[i], nil
+			}
+		}
+		return nil, NewError(ErrNotSubscribed, "workplace %q is not subscribed in this channel", name)
+	}
+
+	switch len(bindings) {
+	case 0:
+		return nil, NewError(ErrNotSubscribed, "WorkplaceBinding not found")
+	case 1:
+		return &bindings[0], nil
+	}
+
+	if prefer != nil {
+		var preferred *WorkplaceBindings
+		count := 0
+		for i := range bindings {
+			if prefer(&bindings[i]) {
+				preferred = &bindings[i]
+				count++
+			}
+		}
+		if count == 1 {
+			return preferred, nil
+		}
+	}
+
+	candidates := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		candidates = append(candidates, b.Workplace)
+	}
+	return nil, &AmbiguousWorkplaceError{Candidates: candidates}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// WorkplaceRole は職場でのユーザーの役割。
+type WorkplaceRole string
+
+const (
+	// RoleMember は自分の勤怠だけを記録・編集できる。
+	RoleMember WorkplaceRole = "member"
+	// RoleManager はメンバーに加え、職場のメンバーの勤怠記録を参照・追加・編集・削除できる。
+	RoleManager WorkplaceRole = "manager"
+	// RoleAdmin はマネージャーに加え、職場名・設定・時給とメンバーの役割を変更できる。
+	RoleAdmin WorkplaceRole = "admin"
+)
+
+// roleRanks は役割の強さ。大きいほど多くの操作ができる
+var roleRanks = map[WorkplaceRole]int{
+	RoleMember:  1,
+	RoleManager: 2,
+	RoleAdmin:   3,
+}
+
+// ParseWorkplaceRole は member / manager / admin を WorkplaceRole に変換する。
+func ParseWorkplaceRole(value string) (WorkplaceRole, error) {
+	role := WorkplaceRole(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := roleRanks[role]; !ok {
+		return "", NewError(ErrValidation, "invalid role %q (member, manager or admin)", value)
+	}
+	return role, nil
+}
+
+// Includes は役割 r が required の役割でできる操作をすべてできるかを返す。
+func (r WorkplaceRole) Includes(required WorkplaceRole) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// WorkplaceMember は職場に登録しているユーザー。
+type WorkplaceMember struct {
+	UserID    string
+	ChannelID string
+	Role      WorkplaceRole
+	JoinedAt  time.Time
+	// LastAction は最後に記録した action。マネージャー以上が名簿を取得した場合だけ設定する
+	LastAction string
+}
+
+// OnShift は勤務中（休憩中を含む）かを返す。
+func (m *WorkplaceMember) OnShift() bool {
+	return m.LastAction != "" && m.LastAction != ActionEnd
+}
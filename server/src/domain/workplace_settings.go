@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkplaceSettings は職場ごとの設定。Workplace に埋め込み、DynamoDB 上は Workplaces テーブルの同じ項目の属性として保存する。
+// WorkplaceBindings は保存せず、読み込むときに SetWorkplace で登録した職場の設定を写す。
+// 未設定（空）の値は既定値として扱うので、設定を追加しても既存データの移行は不要。
+type WorkplaceSettings struct {
+	Timezone        string          `dynamodbav:"timezone"` // IANA タイムゾーン名。空の場合は DefaultTimezone
+	AttributionRule AttributionRule `dynamodbav:"attribution_rule"`
+	// StatutoryBreak が true の場合、法定の休憩が記録されていない勤務から不足分を自動で差し引く
+	StatutoryBreak bool `dynamodbav:"statutory_break"`
+	// HourlyWages は時給の履歴。EffectiveFrom の昇順に並べて保存する
+	HourlyWages []HourlyWage `dynamodbav:"hourly_wages"`
+	// 割増賃金の有無。未設定（nil）の場合は法定どおり割増する
+	OvertimePremium  *bool `dynamodbav:"overtime_premium"`
+	LateNightPremium *bool `dynamodbav:"late_night_premium"`
+	HolidayPremium   *bool `dynamodbav:"holiday_premium"`
+	// LegalHoliday は法定休日の曜日 (sunday 〜 saturday)。空の場合は DefaultLegalHoliday
+	LegalHoliday string `dynamodbav:"legal_holiday"`
+	// ClosingDay は給与の締め日 (1〜31)。0 の場合は月末締め
+	ClosingDay int `dynamodbav:"closing_day"`
+	// RoundingUnit は打刻の時刻を丸める単位（分）。0 の場合は丸めない
+	RoundingUnit int `dynamodbav:"rounding_unit"`
+	// RoundingDirection は打刻の時刻を丸める方向。空の場合は DefaultRoundingDirection
+	RoundingDirection RoundingDirection `dynamodbav:"rounding_direction"`
+}
+
+// DefaultLegalHoliday は設定が無い職場の法定休日。
+const DefaultLegalHoliday = time.Sunday
+
+// Location は職場のタイムゾーンを返す。出勤日・月の境界・時刻の入力はこのタイムゾーンで解釈する。
+func (s WorkplaceSettings) Location() (*time.Location, error) {
+	return LoadTimezone(s.Timezone)
+}
+
+// Attribution は日付をまたぐ勤務の計上ルールを返す。
+func (s WorkplaceSettings) Attribution() AttributionRule {
+	if s.AttributionRule == "" {
+		return DefaultAttributionRule
+	}
+	return s.AttributionRule
+}
+
+// PayrollPeriod は year年month月に締める給与計算期間を職場のタイムゾーンで返す。
+func (s WorkplaceSettings) PayrollPeriod(year int, month time.Month) (from, to time.Time, err error) {
+	loc, err := s.Location()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from, to = PayrollPeriod(year, month, s.ClosingDay, loc)
+	return from, to, nil
+}
+
+// Rounding は打刻の時刻を丸める単位と方向を返す。単位が 0 の場合は丸めない。
+func (s WorkplaceSettings) Rounding() (time.Duration, RoundingDirection) {
+	direction := s.RoundingDirection
+	if direction == "" {
+		direction = DefaultRoundingDirection
+	}
+	return time.Duration(s.RoundingUnit) * time.Minute, direction
+}
+
+// OvertimePremiumEnabled は時間外労働を割増するかを返す。
+func (s WorkplaceSettings) OvertimePremiumEnabled() bool {
+	return s.OvertimePremium == nil || *s.OvertimePremium
+}
+
+// LateNightPremiumEnabled は深夜労働を割増するかを返す。
+func (s WorkplaceSettings) LateNightPremiumEnabled() bool {
+	return s.LateNightPremium == nil || *s.LateNightPremium
+}
+
+// HolidayPremiumEnabled は法定休日の労働を割増するかを返す。
+func (s WorkplaceSettings) HolidayPremiumEnabled() bool {
+	return s.HolidayPremium == nil || *s.HolidayPremium
+}
+
+// LegalHolidayWeekday は法定休日の曜日を返す。
+func (s WorkplaceSettings) LegalHolidayWeekday() time.Weekday {
+	if day, err := parseWeekday(s.LegalHoliday); err == nil && s.LegalHoliday != "" {
+		return day
+	}
+	return DefaultLegalHoliday
+}
+
+// Set は key で指定した設定を value に変更する。key は Slack コマンドや REST API で指定する名前。
+func (s *WorkplaceSettings) Set(key, value string) error {
+	switch key {
+	case "timezone":
+		loc, err := LoadTimezone(value)
+		if err != nil {
+			return err
+		}
+		s.Timezone = loc.String()
+	case "attribution":
+		rule, err := ParseAttributionRule(value)
+		if err != nil {
+			return err
+		}
+		s.AttributionRule = rule
+	case "statutory_break":
+		enabled, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		s.StatutoryBreak = enabled
+	case "overtime_premium", "late_night_premium", "holiday_premium":
+		enabled, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "overtime_premium":
+			s.OvertimePremium = &enabled
+		case "late_night_premium":
+			s.LateNightPremium = &enabled
+		case "holiday_premium":
+			s.HolidayPremium = &enabled
+		}
+	case "closing_day":
+		day, err := parseClosingDay(value)
+		if err != nil {
+			return err
+		}
+		s.ClosingDay = day
+	case "legal_holiday":
+		day, err := parseWeekday(value)
+		if err != nil {
+			return err
+		}
+		s.LegalHoliday = strings.ToLower(day.String())
+	case "rounding_unit":
+		unit, err := parseRoundingUnit(value)
+		if err != nil {
+			return err
+		}
+		s.RoundingUnit = unit
+	case "rounding_direction":
+		direction, err := ParseRoundingDirection(value)
+		if err != nil {
+			return err
+		}
+		s.RoundingDirection = direction
+	default:
+		return NewError(ErrValidation, "unknown workplace setting %q", key)
+	}
+
+	return nil
+}
+
+// parseSwitch は on/off, true/false 形式の設定値を bool に変換する。
+func parseSwitch(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "1", "yes":
+		return true, nil
+	case "off", "false", "0", "no":
+		return false, nil
+	}
+	return false, NewError(ErrValidation, "invalid switch value %q (on or off)", value)
+}
+
+// parseWeekday は曜日の英語名 (sunday, sun など) を time.Weekday に変換する。
+func parseWeekday(value string) (time.Weekday, error) {
+	v := strings.ToLower(value)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if v == name || v == name[:3] {
+			return d, nil
+		}
+	}
+	return time.Sunday, NewError(ErrValidation, "invalid weekday %q (sunday to saturday)", value)
+}
+
+// parseClosingDay は締め日の設定値を変換する。"end" と "0" は月末締め。
+func parseClosingDay(value string) (int, error) {
+	if strings.ToLower(value) == "end" {
+		return 0, nil
+	}
+	day, err := strconv.Atoi(value)
+	if err != nil || day < 0 || day > 31 {
+		return 0, NewError(ErrValidation, "invalid closing day %q (1 to 31, or end)", value)
+	}
+	if day == 31 {
+		// 31日締めは月末締めと同じ
+		return 0, nil
+	}
+	return day, nil
+}
@@ -0,0 +1,70 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Cipher は AES-256-GCM で値を暗号化・復号する。
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher は 32 バイトの鍵から Cipher を作成する。
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// NewCipherFromEnv は TOKEN_ENCRYPTION_KEY 環境変数(base64 でエンコードした 32 バイトの鍵)から Cipher を作成する。
+func NewCipherFromEnv() (*Cipher, error) {
+	encoded := os.Getenv("TOKEN_ENCRYPTION_KEY")
+	if encoded == "" {
+		return nil, errors.New("TOKEN_ENCRYPTION_KEY is not set")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("TOKEN_ENCRYPTION_KEY is not valid base64: %w", err)
+	}
+
+	return NewCipher(key)
+}
+
+// Encrypt は plaintext を暗号化し、nonce を先頭に付けた暗号文を返す。
+// additionalData は暗号文を特定の項目に紐付けるために使い、復号時にも同じ値が必要になる。
+func (c *Cipher) Encrypt(plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt は Encrypt で暗号化した値を復号する。
+func (c *Cipher) Decrypt(ciphertext, additionalData []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	return c.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], additionalData)
+}
@@ -2,20 +2,24 @@ package router
 
 import (
 	"os"
+	"strings"
 
 	echoadapter "github.com/awslabs/aws-lambda-go-api-proxy/echo"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/yuorei/attendance/src/adapter/infrastructure"
+	"github.com/yuorei/attendance/src/adapter/infrastructure/memory"
 	"github.com/yuorei/attendance/src/adapter/presentation"
+	"github.com/yuorei/attendance/src/driver/clock"
 	"github.com/yuorei/attendance/src/usecase"
+	"github.com/yuorei/attendance/src/usecase/port"
 )
 
 func NewRouter() *echoadapter.EchoLambda {
-	infra := infrastructure.NewInfrastructure()
-	repository := usecase.NewRepository(infra)
-
-	handler := presentation.NewHandler(repository)
+	repo := newRepository()
+	systemClock := clock.NewSystemClock()
+	repository := usecase.NewRepository(repo, repo, repo, repo, systemClock)
+	handler := presentation.NewHandler(repository, systemClock)
 
 	// TODO: lambdaを使うと何故かトレースされない。
 	// ctx := context.Background()
@@ -30,10 +34,58 @@ func NewRouter() *echoadapter.EchoLambda {
 	// 	}
 	// }()
 
+	e := NewEcho(handler)
+
+	if os.Getenv("ENV") == "local" {
+		e.Logger.Fatal(e.Start(":8080"))
+	}
+
+	echoLambda := echoadapter.New(e)
+	return echoLambda
+}
+
+// repository は DB の実装が満たすリポジトリのインターフェース
+type repository interface {
+	port.AttendanceLogRepository
+	port.OAuthStateRepository
+	port.SlackTokenRepository
+	port.IdempotencyRepository
+}
+
+// newRepository は DB_DRIVER 環境変数に応じてリポジトリの実装を選ぶ。
+// "memory" を指定すると DynamoDB に接続せずインメモリで動作する。
+func newRepository() repository {
+	switch os.Getenv("DB_DRIVER") {
+	case "memory":
+		return memory.NewMemory()
+	default:
+		return infrastructure.NewInfrastructure()
+	}
+}
+
+// allowOrigins は FRONTEND_ORIGIN 環境変数(カンマ区切り)から CORS を許可するオリジンを返す。
+func allowOrigins() []string {
+	origins := os.Getenv("FRONTEND_ORIGIN")
+	if origins == "" {
+		return []string{"http://localhost:5173"}
+	}
+
+	return strings.Split(origins, ",")
+}
+
+// NewEcho はルーティングとミドルウェアを設定した Echo を返す。
+// Lambda を介さずに httptest などから直接利用できる。
+func NewEcho(handler *presentation.Handler) *echo.Echo {
 	e := echo.New()
+	// ハンドラーが返したエラーはドメインのエラーの種類に応じたステータスコードとエラーコードで返す
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	// OAuth の state Cookie を送受信するため、フロントエンドのオリジンを明示して credentials を許可する
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     allowOrigins(),
+		AllowCredentials: true,
+	}))
 
 	// OpenTelemetry Echoミドルウェア
 	// otelServiceName := os.Getenv("OTEL_SERVICE_NAME")
@@ -41,27 +93,50 @@ func NewRouter() *echoadapter.EchoLambda {
 	// e.Use(otelecho.Middleware(fmt.Sprintf("%s-%s", otelServiceName, env)))
 
 	e.GET("/health", handler.HealthCheck)
-	// e.GET("/attendance/:workplace_id/:year/:month", handler.AttendanceLogListByUserAndMonth)
-	e.POST("/slack/slash/attendance", handler.AttendanceSlach)
+
+	// Slack からのリクエストはすべて署名を検証し、再送には最初の応答を返す
+	slackGroup := e.Group("/slack", handler.VerifySlackSignature, handler.SlackIdempotency)
+	slackGroup.POST("/slash/attendance", handler.AttendanceSlach)
+	// 職場を選ぶボタンなど、メッセージのボタン操作を受け取る（Slack アプリの Interactivity の Request URL）
+	slackGroup.POST("/interactive", handler.SlackInteraction)
 
 	// Slack OAuth endpoints
 	e.GET("/auth/slack", handler.SlackOAuthLogin)
 	e.GET("/auth/slack/callback", handler.SlackOAuthCallback)
-	e.GET("/api/v1/slack/channels", handler.GetSlackChannels)
 
 	// REST API endpoints that mirror Slack functionality
-	api := e.Group("/api/v1")
+	// 呼び出し元のユーザーはセッショントークンから判断する
+	// Idempotency-Key ヘッダーが付いたリクエストは、同じキーで再送されても一度だけ処理する
+	api := e.Group("/api/v1", handler.RequireSession, handler.IdempotencyKey)
+	api.GET("/slack/channels", handler.GetSlackChannels)
 	api.POST("/attendance/check-in", handler.CheckIn)
 	api.POST("/attendance/check-out", handler.CheckOut)
+	api.POST("/attendance/break-start", handler.BreakStart)
+	api.POST("/attendance/break-end", handler.BreakEnd)
 	api.POST("/attendance/workplace/subscribe", handler.SubscribeWorkplace)
+	api.POST("/attendance/workplace/unsubscribe", handler.UnsubscribeWorkplace)
+	api.PUT("/attendance/workplace/name", handler.RenameWorkplace)
+	api.GET("/attendance/workplaces", handler.GetWorkplaces)
+	api.PUT("/attendance/workplace/settings", handler.UpdateWorkplaceSettings)
+	api.PUT("/attendance/workplace/wage", handler.SetHourlyWage)
+	// チームの職場。管理者が作り、メンバーは参加するチャンネルを指定して参加する
+	api.POST("/workplaces", handler.CreateTeamWorkplace)
+	api.GET("/workplaces", handler.GetTeamWorkplaces)
+	api.POST("/workplaces/:id/join", handler.JoinWorkplace)
+	api.GET("/workplaces/:id/members", handler.GetWorkplaceMembers)
+	api.PUT("/workplaces/:id/settings", handler.UpdateTeamWorkplaceSettings)
+	api.PUT("/workplaces/:id/wage", handler.SetTeamWorkplaceHourlyWage)
+	// 役割の変更は admin、メンバーの勤怠記録の参照・修正と監査ログの参照はマネージャー以上だけが行える
+	api.PUT("/workplaces/:id/members/:user_id/role", handler.SetWorkplaceRole)
+	api.GET("/workplaces/:id/attendance", handler.GetMemberAttendanceLogs)
+	api.POST("/workplaces/:id/attendance", handler.AddMemberAttendance)
+	api.PUT("/workplaces/:id/attendance/:log_id", handler.EditMemberAttendance)
+	api.DELETE("/workplaces/:id/attendance/:log_id", handler.DeleteMemberAttendance)
+	api.GET("/workplaces/:id/audit", handler.GetAttendanceAudits)
+	api.GET("/attendance", handler.GetAttendanceLogs)
 	api.GET("/attendance/monthly", handler.GetMonthlyHours)
 	api.PUT("/attendance/edit", handler.EditAttendance)
 	api.DELETE("/attendance/:id", handler.DeleteAttendance)
 
-	if os.Getenv("ENV") == "local" {
-		e.Logger.Fatal(e.Start(":8080"))
-	}
-
-	echoLambda := echoadapter.New(e)
-	return echoLambda
+	return e
 }
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,6 +11,15 @@ import (
 	"github.com/yuorei/attendance/src/usecase/port"
 )
 
+// maxAttendanceRange は期間を指定して一度に取得できる勤怠記録の最大の期間
+const maxAttendanceRange = 366 * 24 * time.Hour
+
+// ページ分割で1ページに返す勤怠記録の件数
+const (
+	defaultAttendancePageSize = 100
+	maxAttendancePageSize     = 1000
+)
+
 type AttendanceLogUseCase struct {
 	attendanceLogRepository port.AttendanceLogRepository
 }
@@ -19,15 +30,57 @@ func NewAttendanceLogRepository(attendanceLogRepository port.AttendanceLogReposi
 	}
 }
 
-func (r *Repository) AddAttendanceLogStart(ctx context.Context, teamId, channelId, userId, action string) (*domain.AttendanceLog, error) {
+func (r *Repository) AddAttendanceLogStart(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	// timestamp は UTC で保存するため、ここではタイムゾーンに依存しない現在時刻を渡す
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBAddAttendanceLogStart(ctx, u.String(), teamId, channelId, userId, binding.ID, action, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *Repository) AddAttendanceLogEnd(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, (*domain.WorkplaceBindings).OnShift)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBAddAttendanceLogEnd(ctx, u.String(), teamId, channelId, userId, binding.ID, action, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *Repository) AddAttendanceLogBreakStart(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, (*domain.WorkplaceBindings).OnShift)
+	if err != nil {
+		return nil, err
+	}
+
 	u, err := uuid.NewV7()
 	if err != nil {
 		return nil, err
 	}
 
-	jst, _ := time.LoadLocation("Asia/Tokyo")
-	now := time.Now().In(jst)
-	result, err := r.attendanceLogRepository.attendanceLogRepository.DBAddAttendanceLogStart(ctx, u.String(), teamId, channelId, userId, action, now)
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBAddAttendanceLogBreakStart(ctx, u.String(), teamId, channelId, userId, binding.ID, action, r.clock.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -35,15 +88,18 @@ func (r *Repository) AddAttendanceLogStart(ctx context.Context, teamId, channelI
 	return result, nil
 }
 
-func (r *Repository) AddAttendanceLogEnd(ctx context.Context, teamId, channelId, userId, action string) (*domain.AttendanceLog, error) {
+func (r *Repository) AddAttendanceLogBreakEnd(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, (*domain.WorkplaceBindings).OnShift)
+	if err != nil {
+		return nil, err
+	}
+
 	u, err := uuid.NewV7()
 	if err != nil {
 		return nil, err
 	}
 
-	jst, _ := time.LoadLocation("Asia/Tokyo")
-	now := time.Now().In(jst)
-	result, err := r.attendanceLogRepository.attendanceLogRepository.DBAddAttendanceLogEnd(ctx, u.String(), teamId, channelId, userId, action, now)
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBAddAttendanceLogBreakEnd(ctx, u.String(), teamId, channelId, userId, binding.ID, action, r.clock.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -51,15 +107,220 @@ func (r *Repository) AddAttendanceLogEnd(ctx context.Context, teamId, channelId,
 	return result, nil
 }
 
-func (r *Repository) SubscribeWorkplace(ctx context.Context, teamId, channelId, userId, workplace string) (*domain.WorkplaceBindings, error) {
+// SubscribeWorkplace は職場を登録する。timezone は IANA タイムゾーン名で、空の場合は domain.DefaultTimezone になる。
+func (r *Repository) SubscribeWorkplace(ctx context.Context, teamId, channelId, userId, workplace, timezone string) (*domain.WorkplaceBindings, error) {
+	workplace, err := domain.ValidateWorkplaceName(workplace)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings domain.WorkplaceSettings
+	if err := settings.Set("timezone", timezone); err != nil {
+		return nil, err
+	}
+
+	workplaceID, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
 	u, err := uuid.NewV7()
 	if err != nil {
 		return nil, err
 	}
 
-	jst, _ := time.LoadLocation("Asia/Tokyo")
-	now := time.Now().In(jst)
-	result, err := r.attendanceLogRepository.attendanceLogRepository.DBSubscribeWorkplace(ctx, u.String(), teamId, channelId, userId, workplace, now)
+	now := r.clock.Now()
+	newWorkplace := domain.NewWorkplace(workplaceID.String(), teamId, userId, workplace, settings, now)
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBSubscribeWorkplace(ctx, u.String(), teamId, channelId, userId, newWorkplace, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UnsubscribeWorkplace は職場の登録を解除する。登録は論理削除するだけなので、勤怠記録は職場名を指定して引き続き参照でき、
+// 同じチャンネルに同じ名前の職場を登録し直すこともできる。
+func (r *Repository) UnsubscribeWorkplace(ctx context.Context, teamId, channelId, userId, workplace string) (*domain.WorkplaceBindings, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBUnsubscribeWorkplace(ctx, binding.ID, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RenameWorkplace は職場名を newName に変更する。勤怠記録は職場の ID で紐付いているため、そのまま新しい名前で参照できる。
+// チャンネルに登録中の他の職場と同じ名前には変更できない。チームの職場は管理者だけが変更でき、チームの他の職場と同じ名前にもできない。
+func (r *Repository) RenameWorkplace(ctx context.Context, teamId, channelId, userId, workplace, newName string) (*domain.WorkplaceBindings, error) {
+	newName, err := domain.ValidateWorkplaceName(newName)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceBindings(ctx, teamId, channelId, userId)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := domain.SelectWorkplaceBinding(bindings, workplace, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bindings {
+		if b.ID != binding.ID && b.Workplace == newName {
+			return nil, domain.NewError(domain.ErrAlreadySubscribed, "workplace %q is already subscribed in this channel", newName)
+		}
+	}
+	target, err := r.manageableWorkplace(ctx, userId, binding.WorkplaceID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Shared {
+		if err := r.checkTeamWorkplaceName(ctx, teamId, target.ID, newName); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBRenameWorkplace(ctx, target.ID, newName, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	binding.SetWorkplace(result)
+
+	return binding, nil
+}
+
+// workplaceBinding は呼び出し元がチャンネルに登録している職場から、職場名 workplace の職場を選ぶ。
+// 職場名を省略した場合は domain.SelectWorkplaceBinding の規則に従い、複数あれば prefer を満たす職場を選ぶ。
+func (r *Repository) workplaceBinding(ctx context.Context, teamId, channelId, userId, workplace string, prefer func(*domain.WorkplaceBindings) bool) (*domain.WorkplaceBindings, error) {
+	bindings, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceBindings(ctx, teamId, channelId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.SelectWorkplaceBinding(bindings, workplace, prefer)
+}
+
+// historyWorkplaceBinding は勤怠記録を参照するための職場を選ぶ。workplaceBinding と同じく登録中の職場から選び、
+// 職場名を指定して登録中の職場に無い場合は、その名前で最後に登録を解除した職場を返す。
+func (r *Repository) historyWorkplaceBinding(ctx context.Context, teamId, channelId, userId, workplace string) (*domain.WorkplaceBindings, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, nil)
+	if !errors.Is(err, domain.ErrNotSubscribed) || strings.TrimSpace(workplace) == "" {
+		return binding, err
+	}
+
+	archived, archivedErr := r.attendanceLogRepository.attendanceLogRepository.DBGetArchivedWorkplaceBindings(ctx, teamId, channelId, userId)
+	if archivedErr != nil {
+		return nil, archivedErr
+	}
+	if result, selectErr := domain.SelectWorkplaceBinding(archived, workplace, nil); selectErr == nil {
+		return result, nil
+	}
+
+	return nil, err
+}
+
+// GetWorkplaceBinding は職場を返す。職場名を指定した場合は登録を解除した職場も対象にする。
+func (r *Repository) GetWorkplaceBinding(ctx context.Context, teamId, channelId, userId, workplace string) (*domain.WorkplaceBindings, error) {
+	return r.historyWorkplaceBinding(ctx, teamId, channelId, userId, workplace)
+}
+
+// GetWorkplaceBindings は呼び出し元がチャンネルに登録している職場を登録順にすべて返す。
+// 登録が無い場合は ErrNotSubscribed を返す。
+func (r *Repository) GetWorkplaceBindings(ctx context.Context, teamId, channelId, userId string) ([]domain.WorkplaceBindings, error) {
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceBindings(ctx, teamId, channelId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, domain.NewError(domain.ErrNotSubscribed, "WorkplaceBinding not found")
+	}
+
+	return result, nil
+}
+
+// GetArchivedWorkplaceBindings は呼び出し元がチャンネルで登録を解除した職場を、解除した日時の新しい順に返す。
+func (r *Repository) GetArchivedWorkplaceBindings(ctx context.Context, teamId, channelId, userId string) ([]domain.WorkplaceBindings, error) {
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetArchivedWorkplaceBindings(ctx, teamId, channelId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetAttendanceLogWorkplace は呼び出し元の勤怠記録 id が属する職場を返す。
+// 記録の時刻を職場のタイムゾーンで解釈するために使う。
+func (r *Repository) GetAttendanceLogWorkplace(ctx context.Context, teamId, channelId, userId, id string) (*domain.Workplace, error) {
+	log, err := r.authorizeAttendanceLog(ctx, teamId, channelId, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplace(ctx, log.WorkplaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateWorkplaceSettings は changes（設定名 → 値）を職場の設定に反映する。
+// 1つでも不正な値があれば何も変更しない。チームの職場の設定は管理者だけが変更できる。
+func (r *Repository) UpdateWorkplaceSettings(ctx context.Context, teamId, channelId, userId, workplace string, changes map[string]string) (*domain.WorkplaceBindings, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, nil)
+	if err != nil {
+		return nil, err
+	}
+	target, err := r.manageableWorkplace(ctx, userId, binding.WorkplaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.updateWorkplaceSettings(ctx, target, changes)
+	if err != nil {
+		return nil, err
+	}
+	binding.SetWorkplace(result)
+
+	return binding, nil
+}
+
+// SetHourlyWage は effectiveFrom (YYYY-MM-DD) から適用する時給を登録する。
+// effectiveFrom が空の場合は職場のタイムゾーンでの今日から適用する。チームの職場の時給は管理者だけが登録できる。
+func (r *Repository) SetHourlyWage(ctx context.Context, teamId, channelId, userId, workplace string, amount int64, effectiveFrom string) (*domain.WorkplaceBindings, error) {
+	binding, err := r.workplaceBinding(ctx, teamId, channelId, userId, workplace, nil)
+	if err != nil {
+		return nil, err
+	}
+	target, err := r.manageableWorkplace(ctx, userId, binding.WorkplaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.setHourlyWage(ctx, target, amount, effectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+	binding.SetWorkplace(result)
+
+	return binding, nil
+}
+
+// GetAttendanceLogListByUserAndRange は [from, to) の勤怠記録をすべて返す。
+func (r *Repository) GetAttendanceLogListByUserAndRange(ctx context.Context, teamId, channelId, userId, workplace string, from, to time.Time) ([]domain.AttendanceLog, error) {
+	if err := validateAttendanceRange(from, to); err != nil {
+		return nil, err
+	}
+	binding, err := r.historyWorkplaceBinding(ctx, teamId, channelId, userId, workplace)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetAttendanceLogListByUserAndRange(ctx, teamId, channelId, userId, binding.ID, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -67,8 +328,25 @@ func (r *Repository) SubscribeWorkplace(ctx context.Context, teamId, channelId,
 	return result, nil
 }
 
-func (r *Repository) GetAttendanceLogListByUserAndMonth(ctx context.Context, teamId, channelId, userId, year, month string) ([]domain.AttendanceLog, error) {
-	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetAttendanceLogListByUserAndMonth(ctx, teamId, channelId, userId, year, month)
+// GetAttendanceLogPageByUserAndRange は [from, to) の勤怠記録を cursor の位置から最大 limit 件返す。
+// limit が 0 以下の場合は defaultAttendancePageSize 件とする。
+func (r *Repository) GetAttendanceLogPageByUserAndRange(ctx context.Context, teamId, channelId, userId, workplace string, from, to time.Time, cursor string, limit int) (*domain.AttendanceLogPage, error) {
+	if err := validateAttendanceRange(from, to); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultAttendancePageSize
+	}
+	if limit > maxAttendancePageSize {
+		return nil, domain.NewError(domain.ErrValidation, "invalid limit: must be at most %d", maxAttendancePageSize)
+	}
+
+	binding, err := r.historyWorkplaceBinding(ctx, teamId, channelId, userId, workplace)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetAttendanceLogPageByUserAndRange(ctx, teamId, channelId, userId, binding.ID, from, to, cursor, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -76,8 +354,35 @@ func (r *Repository) GetAttendanceLogListByUserAndMonth(ctx context.Context, tea
 	return result, nil
 }
 
-func (r *Repository) UpdateAttendanceLog(ctx context.Context, id string, newTimestamp time.Time) (*domain.AttendanceLog, error) {
-	result, err := r.attendanceLogRepository.attendanceLogRepository.DBUpdateAttendanceLog(ctx, id, newTimestamp)
+func validateAttendanceRange(from, to time.Time) error {
+	if !from.Before(to) {
+		return domain.NewError(domain.ErrValidation, "invalid range: from must be before to")
+	}
+	if to.Sub(from) > maxAttendanceRange {
+		return domain.NewError(domain.ErrValidation, "invalid range: must be within %d days", int(maxAttendanceRange.Hours()/24))
+	}
+	return nil
+}
+
+// authorizeAttendanceLog は勤怠記録が呼び出し元の team / channel / user のものか確認し、その記録を返す。
+func (r *Repository) authorizeAttendanceLog(ctx context.Context, teamId, channelId, userId, id string) (*domain.AttendanceLog, error) {
+	log, err := r.attendanceLogRepository.attendanceLogRepository.DBGetAttendanceLog(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !log.IsOwnedBy(teamId, channelId, userId) {
+		return nil, domain.ErrForbidden
+	}
+
+	return log, nil
+}
+
+func (r *Repository) UpdateAttendanceLog(ctx context.Context, teamId, channelId, userId, id string, newTimestamp time.Time) (*domain.AttendanceLog, error) {
+	if _, err := r.authorizeAttendanceLog(ctx, teamId, channelId, userId, id); err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBUpdateAttendanceLog(ctx, teamId, channelId, userId, id, newTimestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +390,12 @@ func (r *Repository) UpdateAttendanceLog(ctx context.Context, id string, newTime
 	return result, nil
 }
 
-func (r *Repository) DeleteAttendanceLog(ctx context.Context, id string) error {
-	err := r.attendanceLogRepository.attendanceLogRepository.DBDeleteAttendanceLog(ctx, id)
+func (r *Repository) DeleteAttendanceLog(ctx context.Context, teamId, channelId, userId, id string) error {
+	if _, err := r.authorizeAttendanceLog(ctx, teamId, channelId, userId, id); err != nil {
+		return err
+	}
+
+	err := r.attendanceLogRepository.attendanceLogRepository.DBDeleteAttendanceLog(ctx, teamId, channelId, userId, id)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+func TestAttendanceLogRecordsClockTime(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	r, clock := newTestRepository(t, start)
+
+	if _, err := r.SubscribeWorkplace(ctx, "T1", "C1", "U1", "本社", "Asia/Tokyo"); err != nil {
+		t.Fatalf("SubscribeWorkplace() error = %v", err)
+	}
+
+	steps := []struct {
+		action  string
+		elapsed time.Duration
+		add     func(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error)
+	}{
+		{action: "start", add: r.AddAttendanceLogStart},
+		{action: "end", elapsed: 8 * time.Hour, add: r.AddAttendanceLogEnd},
+	}
+	var want []time.Time
+	for _, step := range steps {
+		clock.Advance(step.elapsed)
+		log, err := step.add(ctx, "T1", "C1", "U1", "", step.action)
+		if err != nil {
+			t.Fatalf("add %s error = %v", step.action, err)
+		}
+		if !log.Timestamp.Equal(clock.Now()) {
+			t.Errorf("%s timestamp = %v, want %v", step.action, log.Timestamp, clock.Now())
+		}
+		want = append(want, clock.Now())
+	}
+
+	logs, err := r.GetAttendanceLogListByUserAndRange(ctx, "T1", "C1", "U1", "", start, clock.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetAttendanceLogListByUserAndRange() error = %v", err)
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("len(logs) = %d, want %d", len(logs), len(want))
+	}
+	for i, log := range logs {
+		if log.Action != steps[i].action || !log.Timestamp.Equal(want[i]) {
+			t.Errorf("logs[%d] = %s at %v, want %s at %v", i, log.Action, log.Timestamp, steps[i].action, want[i])
+		}
+	}
+}
+
+func TestAttendanceLogStateTransitions(t *testing.T) {
+	ctx := context.Background()
+	r, clock := newTestRepository(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC))
+	if _, err := r.SubscribeWorkplace(ctx, "T1", "C1", "U1", "本社", ""); err != nil {
+		t.Fatalf("SubscribeWorkplace() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		add     func(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error)
+		action  string
+		wantErr error
+	}{
+		{name: "end before start", add: r.AddAttendanceLogEnd, action: domain.ActionEnd, wantErr: domain.ErrNotCheckedIn},
+		{name: "start", add: r.AddAttendanceLogStart, action: domain.ActionStart},
+		{name: "start twice", add: r.AddAttendanceLogStart, action: domain.ActionStart, wantErr: domain.ErrAlreadyCheckedIn},
+		{name: "break end without break", add: r.AddAttendanceLogBreakEnd, action: domain.ActionBreakEnd, wantErr: domain.ErrNotOnBreak},
+		{name: "break start", add: r.AddAttendanceLogBreakStart, action: domain.ActionBreakStart},
+		{name: "end on break", add: r.AddAttendanceLogEnd, action: domain.ActionEnd, wantErr: domain.ErrOnBreak},
+		{name: "break end", add: r.AddAttendanceLogBreakEnd, action: domain.ActionBreakEnd},
+		{name: "end", add: r.AddAttendanceLogEnd, action: domain.ActionEnd},
+		{name: "end twice", add: r.AddAttendanceLogEnd, action: domain.ActionEnd, wantErr: domain.ErrNotCheckedIn},
+	}
+	// 各ステップは前のステップの状態に依存するため、順に実行する
+	for _, tt := range tests {
+		clock.Advance(time.Hour)
+		_, err := tt.add(ctx, "T1", "C1", "U1", "", tt.action)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAttendanceLogWithMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	r, clock := newTestRepository(t, now)
+
+	if _, err := r.AddAttendanceLogStart(ctx, "T1", "C1", "U1", "", "start"); err == nil {
+		t.Fatal("AddAttendanceLogStart() before SubscribeWorkplace succeeded, want error")
+	}
+	binding, err := r.SubscribeWorkplace(ctx, "T1", "C1", "U1", "本社", "")
+	if err != nil {
+		t.Fatalf("SubscribeWorkplace() error = %v", err)
+	}
+	if _, err := r.SubscribeWorkplace(ctx, "T1", "C1", "U1", "本社", ""); !errors.Is(err, domain.ErrAlreadySubscribed) {
+		t.Errorf("SubscribeWorkplace() twice error = %v, want ErrAlreadySubscribed", err)
+	}
+
+	start, err := r.AddAttendanceLogStart(ctx, "T1", "C1", "U1", "", "start")
+	if err != nil {
+		t.Fatalf("AddAttendanceLogStart() error = %v", err)
+	}
+	if start.WorkplaceID != binding.WorkplaceID || start.WorkplaceName != "本社" {
+		t.Errorf("WorkplaceID, WorkplaceName = %q, %q, want %q, 本社", start.WorkplaceID, start.WorkplaceName, binding.WorkplaceID)
+	}
+	if _, err := r.AddAttendanceLogStart(ctx, "T1", "C1", "U1", "", "start"); err == nil {
+		t.Error("AddAttendanceLogStart() twice succeeded, want error")
+	}
+	clock.Advance(time.Hour)
+	if _, err := r.AddAttendanceLogEnd(ctx, "T1", "C1", "U1", "", "end"); err != nil {
+		t.Fatalf("AddAttendanceLogEnd() error = %v", err)
+	}
+	if _, err := r.AddAttendanceLogEnd(ctx, "T1", "C1", "U1", "", "end"); err == nil {
+		t.Error("AddAttendanceLogEnd() twice succeeded, want error")
+	}
+
+	logs, err := r.GetAttendanceLogListByUserAndRange(ctx, "T1", "C1", "U1", "", now, clock.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetAttendanceLogListByUserAndRange() error = %v", err)
+	}
+	if len(logs) != 2 || logs[0].Action != "start" || logs[1].Action != "end" {
+		t.Fatalf("logs = %+v, want start and end", logs)
+	}
+
+	newTimestamp := now.Add(-time.Minute)
+	updated, err := r.UpdateAttendanceLog(ctx, "T1", "C1", "U1", start.ID, newTimestamp)
+	if err != nil {
+		t.Fatalf("UpdateAttendanceLog() error = %v", err)
+	}
+	if !updated.Timestamp.Equal(newTimestamp) {
+		t.Errorf("updated Timestamp = %v, want %v", updated.Timestamp, newTimestamp)
+	}
+	if err := r.DeleteAttendanceLog(ctx, "T1", "C1", "U1", start.ID); err != nil {
+		t.Fatalf("DeleteAttendanceLog() error = %v", err)
+	}
+	if _, err := r.UpdateAttendanceLog(ctx, "T1", "C1", "U1", start.ID, newTimestamp); err == nil {
+		t.Error("UpdateAttendanceLog() after delete succeeded, want error")
+	}
+}
+
+func TestAttendanceLogOwnership(t *testing.T) {
+	ctx := context.Background()
+	r, _ := newTestRepository(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC))
+	for _, user := range []string{"U1", "U2"} {
+		if _, err := r.SubscribeWorkplace(ctx, "T1", "C1", user, "本社", ""); err != nil {
+			t.Fatalf("SubscribeWorkplace(%s) error = %v", user, err)
+		}
+	}
+	log, err := r.AddAttendanceLogStart(ctx, "T1", "C1", "U1", "", "start")
+	if err != nil {
+		t.Fatalf("AddAttendanceLogStart() error = %v", err)
+	}
+
+	// 他のユーザーの記録は編集・削除できない
+	newTimestamp := log.Timestamp.Add(-30 * time.Minute)
+	if _, err := r.UpdateAttendanceLog(ctx, "T1", "C1", "U2", log.ID, newTimestamp); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("UpdateAttendanceLog by U2 error = %v, want ErrForbidden", err)
+	}
+	if err := r.DeleteAttendanceLog(ctx, "T1", "C1", "U2", log.ID); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("DeleteAttendanceLog by U2 error = %v, want ErrForbidden", err)
+	}
+	if _, err := r.UpdateAttendanceLog(ctx, "T2", "C1", "U1", log.ID, newTimestamp); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("UpdateAttendanceLog from another team error = %v, want ErrForbidden", err)
+	}
+
+	updated, err := r.UpdateAttendanceLog(ctx, "T1", "C1", "U1", log.ID, newTimestamp)
+	if err != nil {
+		t.Fatalf("UpdateAttendanceLog by U1 error = %v", err)
+	}
+	if !updated.Timestamp.Equal(newTimestamp) || updated.UpdatedBy != "U1" {
+		t.Errorf("updated log = %v by %q, want %v by U1", updated.Timestamp, updated.UpdatedBy, newTimestamp)
+	}
+	if err := r.DeleteAttendanceLog(ctx, "T1", "C1", "U1", log.ID); err != nil {
+		t.Fatalf("DeleteAttendanceLog by U1 error = %v", err)
+	}
+}
+
+func TestGetAttendanceLogListByUserAndRangeForPayrollPeriod(t *testing.T) {
+	ctx := context.Background()
+	r, clock := newTestRepository(t, time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC))
+	if _, err := r.SubscribeWorkplace(ctx, "T1", "C1", "U1", "本社", "Asia/Tokyo"); err != nil {
+		t.Fatalf("SubscribeWorkplace() error = %v", err)
+	}
+
+	// 5/25 と 5/26 と 6/25 と 6/26 の 09:00 JST から1時間ずつ勤務する
+	starts := []time.Time{
+		time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 26, 0, 0, 0, 0, time.UTC),
+	}
+	for _, start := range starts {
+		clock.Set(start)
+		if _, err := r.AddAttendanceLogStart(ctx, "T1", "C1", "U1", "", "start"); err != nil {
+			t.Fatalf("AddAttendanceLogStart() error = %v", err)
+		}
+		clock.Advance(time.Hour)
+		if _, err := r.AddAttendanceLogEnd(ctx, "T1", "C1", "U1", "", "end"); err != nil {
+			t.Fatalf("AddAttendanceLogEnd() error = %v", err)
+		}
+	}
+
+	// 25日締めの6月分は 5/26〜6/25 の期間になる
+	loc, _ := time.LoadLocation("Asia/Tokyo")
+	from, to := domain.PayrollPeriod(2025, time.June, 25, loc)
+	logs, err := r.GetAttendanceLogListByUserAndRange(ctx, "T1", "C1", "U1", "", from, to)
+	if err != nil {
+		t.Fatalf("GetAttendanceLogListByUserAndRange() error = %v", err)
+	}
+	if len(logs) != 4 || !logs[0].Timestamp.Equal(starts[1]) || !logs[2].Timestamp.Equal(starts[2]) {
+		t.Errorf("logs = %+v, want the shifts on 5/26 and 6/25", logs)
+	}
+}
+
+func TestGetAttendanceLogListByUserAndRangeValidation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	r, _ := newTestRepository(t, now)
+	if _, err := r.SubscribeWorkplace(ctx, "T1", "C1", "U1", "本社", ""); err != nil {
+		t.Fatalf("SubscribeWorkplace() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+	}{
+		{name: "from equals to", from: now, to: now},
+		{name: "from after to", from: now, to: now.Add(-time.Hour)},
+		{name: "longer than 366 days", from: now, to: now.Add(maxAttendanceRange + time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := r.GetAttendanceLogListByUserAndRange(ctx, "T1", "C1", "U1", "", tt.from, tt.to); !errors.Is(err, domain.ErrValidation) {
+				t.Errorf("GetAttendanceLogListByUserAndRange() error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestSetHourlyWageDefaultsToTodayInWorkplaceTimezone(t *testing.T) {
+	ctx := context.Background()
+	// 東京では 2025-06-01 00:30、ニューヨークでは 2025-05-31 11:30
+	now := time.Date(2025, 5, 31, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{timezone: "Asia/Tokyo", want: "2025-06-01"},
+		{timezone: "America/New_York", want: "2025-05-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			r, _ := newTestRepository(t, now)
+			if _, err := r.SubscribeWorkplace(ctx, "T1", "C1", "U1", "本社", tt.timezone); err != nil {
+				t.Fatalf("SubscribeWorkplace() error = %v", err)
+			}
+			binding, err := r.SetHourlyWage(ctx, "T1", "C1", "U1", "", 1200, "")
+			if err != nil {
+				t.Fatalf("SetHourlyWage() error = %v", err)
+			}
+			if len(binding.HourlyWages) != 1 || binding.HourlyWages[0].EffectiveFrom != tt.want {
+				t.Errorf("HourlyWages = %+v, want effective from %s", binding.HourlyWages, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/yuorei/attendance/src/domain"
+	"github.com/yuorei/attendance/src/usecase/port"
+)
+
+type IdempotencyUseCase struct {
+	idempotencyRepository port.IdempotencyRepository
+}
+
+func NewIdempotencyRepository(idempotencyRepository port.IdempotencyRepository) *IdempotencyUseCase {
+	return &IdempotencyUseCase{
+		idempotencyRepository: idempotencyRepository,
+	}
+}
+
+// BeginIdempotentRequest は key のリクエストの処理を始める。
+// 初めてのリクエストであれば nil を返し、呼び出し側はリクエストを処理してから CompleteIdempotentRequest を呼ぶ。
+// 同じリクエストの応答が保存されていればその記録を返す。処理中の場合は ErrIdempotencyInProgress、
+// 同じキーで内容の異なるリクエストの場合は ErrIdempotencyKeyMismatch を返す。
+// 処理中の記録は IdempotencyLeaseTTL で期限切れになり、応答を保存したときに IdempotencyTTL まで延長する。
+func (r *Repository) BeginIdempotentRequest(ctx context.Context, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	now := r.clock.Now()
+	record := &domain.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      domain.IdempotencyInProgress,
+		ExpiresAt:   now.Add(domain.IdempotencyLeaseTTL),
+	}
+
+	existing, err := r.idempotencyRepository.idempotencyRepository.DBReserveIdempotencyKey(ctx, record, now)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+	if existing.RequestHash != requestHash {
+		return nil, domain.ErrIdempotencyKeyMismatch
+	}
+	if existing.Status != domain.IdempotencyCompleted {
+		return nil, domain.ErrIdempotencyInProgress
+	}
+
+	return existing, nil
+}
+
+// CompleteIdempotentRequest は key のリクエストの応答を保存する。
+func (r *Repository) CompleteIdempotentRequest(ctx context.Context, key, requestHash string, statusCode int, contentType string, body []byte) error {
+	record := &domain.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      domain.IdempotencyCompleted,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+		ExpiresAt:   r.clock.Now().Add(domain.IdempotencyTTL),
+	}
+
+	return r.idempotencyRepository.idempotencyRepository.DBSaveIdempotencyRecord(ctx, record)
+}
+
+// AbortIdempotentRequest はリクエストの処理に失敗したときに記録を削除し、同じキーで再試行できるようにする。
+func (r *Repository) AbortIdempotentRequest(ctx context.Context, key string) error {
+	return r.idempotencyRepository.idempotencyRepository.DBDeleteIdempotencyRecord(ctx, key)
+}
@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+func TestBeginIdempotentRequestAfterLeaseExpired(t *testing.T) {
+	ctx := context.Background()
+	r, clock := newTestRepository(t, time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+
+	if record, err := r.BeginIdempotentRequest(ctx, "api#T1#U1#key-1", "hash"); err != nil || record != nil {
+		t.Fatalf("first BeginIdempotentRequest() = %v, %v, want nil, nil", record, err)
+	}
+	if _, err := r.BeginIdempotentRequest(ctx, "api#T1#U1#key-1", "hash"); !errors.Is(err, domain.ErrIdempotencyInProgress) {
+		t.Fatalf("retry while in progress error = %v, want ErrIdempotencyInProgress", err)
+	}
+
+	// 最初のリクエストが応答を保存できないまま終了し、処理中の記録の期限が切れた後の再試行
+	clock.Advance(domain.IdempotencyLeaseTTL)
+	if record, err := r.BeginIdempotentRequest(ctx, "api#T1#U1#key-1", "hash"); err != nil || record != nil {
+		t.Fatalf("retry after the lease expired = %v, %v, want nil, nil", record, err)
+	}
+	if err := r.CompleteIdempotentRequest(ctx, "api#T1#U1#key-1", "hash", http.StatusCreated, "application/json", []byte(`{"success":true}`)); err != nil {
+		t.Fatalf("CompleteIdempotentRequest() error = %v", err)
+	}
+
+	// 保存した応答はリースの期間を過ぎても IdempotencyTTL の間は返す
+	clock.Advance(domain.IdempotencyLeaseTTL + time.Minute)
+	record, err := r.BeginIdempotentRequest(ctx, "api#T1#U1#key-1", "hash")
+	if err != nil {
+		t.Fatalf("BeginIdempotentRequest() after completion error = %v", err)
+	}
+	if record == nil || record.Status != domain.IdempotencyCompleted || record.StatusCode != http.StatusCreated {
+		t.Errorf("BeginIdempotentRequest() after completion = %+v, want the saved response", record)
+	}
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// マネージャーによるメンバーの勤怠記録の参照・修正。チームの職場のマネージャー以上だけが行え、
+// 追加・編集・削除はすべて操作したマネージャーを記録した監査ログを残す。
+
+// GetMemberAttendanceLogs は職場 workplaceId のメンバー memberId の [from, to) の勤怠記録を時刻順に返す。
+// memberId が空の場合はすべてのメンバーの記録を返す。
+func (r *Repository) GetMemberAttendanceLogs(ctx context.Context, teamId, userId, workplaceId, memberId string, from, to time.Time) ([]domain.AttendanceLog, error) {
+	workplace, err := r.managedWorkplace(ctx, teamId, userId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAttendanceRange(from, to); err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceAttendanceLogs(ctx, workplace.ID, memberId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetMemberAttendanceLogWorkplace は勤怠記録 id の職場を返す。修正する時刻を職場のタイムゾーンで解釈するために使う。
+// userId が記録の職場のマネージャー以上でない場合は ErrForbidden を返す。
+func (r *Repository) GetMemberAttendanceLogWorkplace(ctx context.Context, teamId, userId, id string) (*domain.Workplace, error) {
+	log, err := r.attendanceLogRepository.attendanceLogRepository.DBGetAttendanceLog(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if log.TeamID != teamId {
+		return nil, domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+	}
+
+	return r.managedWorkplace(ctx, teamId, userId, log.WorkplaceID)
+}
+
+// AddMemberAttendanceLog は職場 workplaceId のメンバー memberId の勤怠記録として action を timestamp の時刻で追加する。
+// 退勤の打刻忘れなどを後から補うためのもので、メンバーの最新の記録より後、現在より前の時刻でなければならない。
+func (r *Repository) AddMemberAttendanceLog(ctx context.Context, teamId, userId, workplaceId, memberId, action string, timestamp time.Time) (*domain.AttendanceLog, error) {
+	workplace, err := r.managedWorkplace(ctx, teamId, userId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.clock.Now()
+	if timestamp.After(now) {
+		return nil, domain.NewError(domain.ErrValidation, "cannot add attendance log in the future")
+	}
+	if now.Sub(timestamp) > maxAttendanceRange {
+		return nil, domain.NewError(domain.ErrValidation, "attendance log must be within the last %d days", int(maxAttendanceRange.Hours()/24))
+	}
+
+	members, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceMembers(ctx, workplace.ID)
+	if err != nil {
+		return nil, err
+	}
+	var binding *domain.WorkplaceBindings
+	for i := range members {
+		if members[i].UserId == memberId {
+			binding = &members[i]
+			break
+		}
+	}
+	if binding == nil {
+		return nil, domain.NewError(domain.ErrNotFound, "user %s is not a member of workplace %q", memberId, workplace.Name)
+	}
+
+	// 打刻の状態は最新の記録で決まるため、それより前の時刻に差し込むことはできない
+	later, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceAttendanceLogs(ctx, workplace.ID, memberId, timestamp, now.Add(time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	if len(later) > 0 {
+		return nil, domain.NewError(domain.ErrValidation, "user %s already has attendance logs at or after %s", memberId, domain.FormatTimestamp(timestamp))
+	}
+
+	u, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	a, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	after := timestamp.UTC().Truncate(time.Millisecond)
+	newLog := domain.AttendanceLog{ID: u.String(), TeamID: teamId, UserID: memberId, Action: action, WorkplaceID: workplace.ID}
+	audit := domain.NewAttendanceAudit(a.String(), userId, domain.AuditCreate, &newLog, nil, &after, now)
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBAddMemberAttendanceLog(ctx, newLog.ID, binding.ID, action, after, audit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateMemberAttendanceLog は職場 workplaceId の勤怠記録 id の時刻を newTimestamp に変更する。
+func (r *Repository) UpdateMemberAttendanceLog(ctx context.Context, teamId, userId, workplaceId, id string, newTimestamp time.Time) (*domain.AttendanceLog, error) {
+	workplace, log, err := r.memberAttendanceLog(ctx, teamId, userId, workplaceId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	before := log.Timestamp
+	after := newTimestamp.UTC().Truncate(time.Millisecond)
+	audit := domain.NewAttendanceAudit(a.String(), userId, domain.AuditUpdate, log, &before, &after, r.clock.Now())
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBUpdateMemberAttendanceLog(ctx, workplace.ID, id, after, audit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteMemberAttendanceLog は職場 workplaceId の勤怠記録 id を削除する。
+func (r *Repository) DeleteMemberAttendanceLog(ctx context.Context, teamId, userId, workplaceId, id string) error {
+	workplace, log, err := r.memberAttendanceLog(ctx, teamId, userId, workplaceId, id)
+	if err != nil {
+		return err
+	}
+
+	a, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	before := log.Timestamp
+	audit := domain.NewAttendanceAudit(a.String(), userId, domain.AuditDelete, log, &before, nil, r.clock.Now())
+	err = r.attendanceLogRepository.attendanceLogRepository.DBDeleteMemberAttendanceLog(ctx, workplace.ID, id, audit)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAttendanceAudits は職場 workplaceId で [from, to) にマネージャーが行った勤怠記録の追加・編集・削除の履歴を古い順に返す。
+func (r *Repository) GetAttendanceAudits(ctx context.Context, teamId, userId, workplaceId string, from, to time.Time) ([]domain.AttendanceAudit, error) {
+	workplace, err := r.managedWorkplace(ctx, teamId, userId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAttendanceRange(from, to); err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetAttendanceAudits(ctx, workplace.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// managedWorkplace は team のチームの職場 workplaceId を返す。userId が職場のマネージャー以上でない場合は ErrForbidden を返す。
+func (r *Repository) managedWorkplace(ctx context.Context, teamId, userId, workplaceId string) (*domain.Workplace, error) {
+	workplace, err := r.teamWorkplace(ctx, teamId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := workplace.Authorize(userId, domain.RoleManager); err != nil {
+		return nil, err
+	}
+
+	return workplace, nil
+}
+
+// memberAttendanceLog は userId がマネージャーである職場 workplaceId と、その職場の勤怠記録 id を返す。
+// 他の職場の記録は存在しないものとして ErrNotFound を返す。
+func (r *Repository) memberAttendanceLog(ctx context.Context, teamId, userId, workplaceId, id string) (*domain.Workplace, *domain.AttendanceLog, error) {
+	workplace, err := r.managedWorkplace(ctx, teamId, userId, workplaceId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log, err := r.attendanceLogRepository.attendanceLogRepository.DBGetAttendanceLog(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if log.WorkplaceID != workplace.ID {
+		return nil, nil, domain.NewError(domain.ErrNotFound, "AttendanceLog not found")
+	}
+
+	return workplace, log, nil
+}
@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+	"github.com/yuorei/attendance/src/usecase/port"
+)
+
+type OAuthStateUseCase struct {
+	oauthStateRepository port.OAuthStateRepository
+}
+
+func NewOAuthStateRepository(oauthStateRepository port.OAuthStateRepository) *OAuthStateUseCase {
+	return &OAuthStateUseCase{
+		oauthStateRepository: oauthStateRepository,
+	}
+}
+
+// SaveOAuthState は OAuth の state を expiresAt まで有効な値として保管する。
+func (r *Repository) SaveOAuthState(ctx context.Context, state string, expiresAt time.Time) error {
+	return r.oauthStateRepository.oauthStateRepository.DBSaveOAuthState(ctx, domain.OAuthStateID(state), expiresAt)
+}
+
+// ConsumeOAuthState は保管した state を削除する。state は一度しか使えないよう、
+// 保管されていないか期限切れ・使用済みの場合は domain.ErrOAuthStateNotFound を返す。
+func (r *Repository) ConsumeOAuthState(ctx context.Context, state string) error {
+	return r.oauthStateRepository.oauthStateRepository.DBDeleteOAuthState(ctx, domain.OAuthStateID(state), r.clock.Now())
+}
@@ -7,21 +7,73 @@ import (
 	"github.com/yuorei/attendance/src/domain"
 )
 
+// workplace は操作する職場名。空の場合は SelectWorkplaceBinding の規則で職場を選ぶ。
 type AttendanceLogInputPort interface {
-	AddAttendanceLogStart(ctx context.Context, teamId, channelId, userId, action string) (*domain.AttendanceLog, error)
-	AddAttendanceLogEnd(ctx context.Context, teamId, channelId, userId, action string) (*domain.AttendanceLog, error)
-	SubscribeWorkplace(ctx context.Context, teamId, channelId, userId, workplace string) (*domain.WorkplaceBindings, error)
-	GetAttendanceLogListByUserAndMonth(ctx context.Context, teamId, channelId, userId, year, month string) ([]domain.AttendanceLog, error)
-	UpdateAttendanceLog(ctx context.Context, id string, newTimestamp time.Time) (*domain.AttendanceLog, error)
-	DeleteAttendanceLog(ctx context.Context, id string) error
+	AddAttendanceLogStart(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error)
+	AddAttendanceLogEnd(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error)
+	AddAttendanceLogBreakStart(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error)
+	AddAttendanceLogBreakEnd(ctx context.Context, teamId, channelId, userId, workplace, action string) (*domain.AttendanceLog, error)
+	SubscribeWorkplace(ctx context.Context, teamId, channelId, userId, workplace, timezone string) (*domain.WorkplaceBindings, error)
+	UnsubscribeWorkplace(ctx context.Context, teamId, channelId, userId, workplace string) (*domain.WorkplaceBindings, error)
+	RenameWorkplace(ctx context.Context, teamId, channelId, userId, workplace, newName string) (*domain.WorkplaceBindings, error)
+	GetWorkplaceBinding(ctx context.Context, teamId, channelId, userId, workplace string) (*domain.WorkplaceBindings, error)
+	GetWorkplaceBindings(ctx context.Context, teamId, channelId, userId string) ([]domain.WorkplaceBindings, error)
+	GetArchivedWorkplaceBindings(ctx context.Context, teamId, channelId, userId string) ([]domain.WorkplaceBindings, error)
+	GetAttendanceLogWorkplace(ctx context.Context, teamId, channelId, userId, id string) (*domain.Workplace, error)
+	UpdateWorkplaceSettings(ctx context.Context, teamId, channelId, userId, workplace string, changes map[string]string) (*domain.WorkplaceBindings, error)
+	SetHourlyWage(ctx context.Context, teamId, channelId, userId, workplace string, amount int64, effectiveFrom string) (*domain.WorkplaceBindings, error)
+	CreateTeamWorkplace(ctx context.Context, teamId, userId, workplace, timezone string) (*domain.Workplace, error)
+	GetTeamWorkplaces(ctx context.Context, teamId string) ([]domain.Workplace, error)
+	JoinWorkplace(ctx context.Context, teamId, channelId, userId, workplaceId string) (*domain.WorkplaceBindings, error)
+	GetWorkplaceMembers(ctx context.Context, teamId, userId, workplaceId string) (*domain.Workplace, []domain.WorkplaceMember, error)
+	SetWorkplaceRole(ctx context.Context, teamId, userId, workplaceId, memberId, role string) (*domain.Workplace, error)
+	UpdateTeamWorkplaceSettings(ctx context.Context, teamId, userId, workplaceId string, changes map[string]string) (*domain.Workplace, error)
+	SetTeamWorkplaceHourlyWage(ctx context.Context, teamId, userId, workplaceId string, amount int64, effectiveFrom string) (*domain.Workplace, error)
+	GetAttendanceLogListByUserAndRange(ctx context.Context, teamId, channelId, userId, workplace string, from, to time.Time) ([]domain.AttendanceLog, error)
+	GetAttendanceLogPageByUserAndRange(ctx context.Context, teamId, channelId, userId, workplace string, from, to time.Time, cursor string, limit int) (*domain.AttendanceLogPage, error)
+	UpdateAttendanceLog(ctx context.Context, teamId, channelId, userId, id string, newTimestamp time.Time) (*domain.AttendanceLog, error)
+	DeleteAttendanceLog(ctx context.Context, teamId, channelId, userId, id string) error
+	GetMemberAttendanceLogs(ctx context.Context, teamId, userId, workplaceId, memberId string, from, to time.Time) ([]domain.AttendanceLog, error)
+	GetMemberAttendanceLogWorkplace(ctx context.Context, teamId, userId, id string) (*domain.Workplace, error)
+	AddMemberAttendanceLog(ctx context.Context, teamId, userId, workplaceId, memberId, action string, timestamp time.Time) (*domain.AttendanceLog, error)
+	UpdateMemberAttendanceLog(ctx context.Context, teamId, userId, workplaceId, id string, newTimestamp time.Time) (*domain.AttendanceLog, error)
+	DeleteMemberAttendanceLog(ctx context.Context, teamId, userId, workplaceId, id string) error
+	GetAttendanceAudits(ctx context.Context, teamId, userId, workplaceId string, from, to time.Time) ([]domain.AttendanceAudit, error)
 }
 
+// bindingId は WorkplaceBindings の ID。呼び出し元の team / channel / user のものでなければ ErrNotSubscribed になる。
+// workplaceId は Workplace の ID。
+// DBGetWorkplaceBindings は登録を解除した職場を含まず、DBGetArchivedWorkplaceBindings は登録を解除した職場だけを返す。
+// DBGetTeamWorkplaces はチームの職場 (Shared) だけを、DBGetWorkplaceMembers は職場に登録中のすべてのユーザーの登録を、それぞれ作成順に返す。
+// DBGetWorkplaceMembers は last_action が無い登録の LastAction を最新の勤怠記録から求めて返す。
+// 勤怠記録の取得はチームの職場でも bindingId の登録のユーザーの記録だけを返す。
+// DBxxxMemberAttendanceLog はマネージャーの操作で、登録の所有者を確認しない代わりに audit を同じトランザクションで保存する。
+// WorkplaceBindings と AttendanceLog は職場名・設定を設定した状態で返す。
 type AttendanceLogRepository interface {
-	DBAddAttendanceLogStart(ctx context.Context, id, teamId, channelId, userId, action string, timestamp time.Time) (*domain.AttendanceLog, error)
-	DBAddAttendanceLogEnd(ctx context.Context, id, teamId, channelId, userId, action string, timestamp time.Time) (*domain.AttendanceLog, error)
-	DBSubscribeWorkplace(ctx context.Context, id, teamId, channelId, userId, workplace string, createdAt time.Time) (*domain.WorkplaceBindings, error)
-	DBGetAttendanceLogListByUserAndMonth(ctx context.Context, teamId, channelId, userId, year, month string) ([]domain.AttendanceLog, error)
+	DBAddAttendanceLogStart(ctx context.Context, id, teamId, channelId, userId, bindingId, action string, timestamp time.Time) (*domain.AttendanceLog, error)
+	DBAddAttendanceLogEnd(ctx context.Context, id, teamId, channelId, userId, bindingId, action string, timestamp time.Time) (*domain.AttendanceLog, error)
+	DBAddAttendanceLogBreakStart(ctx context.Context, id, teamId, channelId, userId, bindingId, action string, timestamp time.Time) (*domain.AttendanceLog, error)
+	DBAddAttendanceLogBreakEnd(ctx context.Context, id, teamId, channelId, userId, bindingId, action string, timestamp time.Time) (*domain.AttendanceLog, error)
+	DBSubscribeWorkplace(ctx context.Context, id, teamId, channelId, userId string, workplace domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error)
+	DBUnsubscribeWorkplace(ctx context.Context, id string, deletedAt time.Time) (*domain.WorkplaceBindings, error)
+	DBGetWorkplaceBindings(ctx context.Context, teamId, channelId, userId string) ([]domain.WorkplaceBindings, error)
+	DBGetArchivedWorkplaceBindings(ctx context.Context, teamId, channelId, userId string) ([]domain.WorkplaceBindings, error)
+	DBGetWorkplace(ctx context.Context, workplaceId string) (*domain.Workplace, error)
+	DBCreateWorkplace(ctx context.Context, workplace domain.Workplace) (*domain.Workplace, error)
+	DBGetTeamWorkplaces(ctx context.Context, teamId string) ([]domain.Workplace, error)
+	DBJoinWorkplace(ctx context.Context, id, teamId, channelId, userId string, workplace domain.Workplace, createdAt time.Time) (*domain.WorkplaceBindings, error)
+	DBGetWorkplaceMembers(ctx context.Context, workplaceId string) ([]domain.WorkplaceBindings, error)
+	DBUpdateWorkplaceRoles(ctx context.Context, workplaceId string, roles map[string]domain.WorkplaceRole, updatedAt time.Time) (*domain.Workplace, error)
+	DBRenameWorkplace(ctx context.Context, workplaceId, name string, updatedAt time.Time) (*domain.Workplace, error)
+	DBUpdateWorkplaceSettings(ctx context.Context, workplaceId string, settings domain.WorkplaceSettings, updatedAt time.Time) (*domain.Workplace, error)
+	DBGetAttendanceLogListByUserAndRange(ctx context.Context, teamId, channelId, userId, bindingId string, from, to time.Time) ([]domain.AttendanceLog, error)
+	DBGetAttendanceLogPageByUserAndRange(ctx context.Context, teamId, channelId, userId, bindingId string, from, to time.Time, cursor string, limit int) (*domain.AttendanceLogPage, error)
 	DBGetAttendanceLog(ctx context.Context, id string) (*domain.AttendanceLog, error)
-	DBUpdateAttendanceLog(ctx context.Context, id string, newTimestamp time.Time) (*domain.AttendanceLog, error)
-	DBDeleteAttendanceLog(ctx context.Context, id string) error
+	DBUpdateAttendanceLog(ctx context.Context, teamId, channelId, userId, id string, newTimestamp time.Time) (*domain.AttendanceLog, error)
+	DBDeleteAttendanceLog(ctx context.Context, teamId, channelId, userId, id string) error
+	DBGetWorkplaceAttendanceLogs(ctx context.Context, workplaceId, userId string, from, to time.Time) ([]domain.AttendanceLog, error)
+	DBAddMemberAttendanceLog(ctx context.Context, id, bindingId, action string, timestamp time.Time, audit domain.AttendanceAudit) (*domain.AttendanceLog, error)
+	DBUpdateMemberAttendanceLog(ctx context.Context, workplaceId, id string, newTimestamp time.Time, audit domain.AttendanceAudit) (*domain.AttendanceLog, error)
+	DBDeleteMemberAttendanceLog(ctx context.Context, workplaceId, id string, audit domain.AttendanceAudit) error
+	DBGetAttendanceAudits(ctx context.Context, workplaceId string, from, to time.Time) ([]domain.AttendanceAudit, error)
 }
@@ -0,0 +1,8 @@
+package port
+
+import "time"
+
+// Clock は現在時刻を返す。テストでは時刻を固定した実装に差し替える。
+type Clock interface {
+	Now() time.Time
+}
@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+type IdempotencyInputPort interface {
+	BeginIdempotentRequest(ctx context.Context, key, requestHash string) (*domain.IdempotencyRecord, error)
+	CompleteIdempotentRequest(ctx context.Context, key, requestHash string, statusCode int, contentType string, body []byte) error
+	AbortIdempotentRequest(ctx context.Context, key string) error
+}
+
+type IdempotencyRepository interface {
+	DBReserveIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, error)
+	DBSaveIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error
+	DBDeleteIdempotencyRecord(ctx context.Context, key string) error
+}
@@ -0,0 +1,17 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+type OAuthStateInputPort interface {
+	SaveOAuthState(ctx context.Context, state string, expiresAt time.Time) error
+	ConsumeOAuthState(ctx context.Context, state string) error
+}
+
+type OAuthStateRepository interface {
+	DBSaveOAuthState(ctx context.Context, id string, expiresAt time.Time) error
+	// DBDeleteOAuthState は期限内の state を削除する。無いか期限切れの場合は domain.ErrOAuthStateNotFound を返す。
+	DBDeleteOAuthState(ctx context.Context, id string, now time.Time) error
+}
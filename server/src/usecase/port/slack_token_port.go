@@ -0,0 +1,17 @@
+package port
+
+import (
+	"context"
+
+	"github.com/yuorei/attendance/src/domain"
+)
+
+type SlackTokenInputPort interface {
+	SaveSlackToken(ctx context.Context, teamId, userId, botToken, userToken, scope string) error
+	GetSlackToken(ctx context.Context, teamId, userId string) (*domain.SlackToken, error)
+}
+
+type SlackTokenRepository interface {
+	DBSaveSlackToken(ctx context.Context, token *domain.SlackToken) error
+	DBGetSlackToken(ctx context.Context, teamId, userId string) (*domain.SlackToken, error)
+}
@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/yuorei/attendance/src/domain"
+	"github.com/yuorei/attendance/src/usecase/port"
+)
+
+type SlackTokenUseCase struct {
+	slackTokenRepository port.SlackTokenRepository
+}
+
+func NewSlackTokenRepository(slackTokenRepository port.SlackTokenRepository) *SlackTokenUseCase {
+	return &SlackTokenUseCase{
+		slackTokenRepository: slackTokenRepository,
+	}
+}
+
+func (r *Repository) SaveSlackToken(ctx context.Context, teamId, userId, botToken, userToken, scope string) error {
+	token := &domain.SlackToken{
+		TeamID:    teamId,
+		UserID:    userId,
+		BotToken:  botToken,
+		UserToken: userToken,
+		Scope:     scope,
+		UpdatedAt: r.clock.Now(),
+	}
+
+	return r.slackTokenRepository.slackTokenRepository.DBSaveSlackToken(ctx, token)
+}
+
+func (r *Repository) GetSlackToken(ctx context.Context, teamId, userId string) (*domain.SlackToken, error) {
+	result, err := r.slackTokenRepository.slackTokenRepository.DBGetSlackToken(ctx, teamId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
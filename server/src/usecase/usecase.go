@@ -1,27 +1,43 @@
 package usecase
 
 import (
-	"github.com/yuorei/attendance/src/adapter/infrastructure"
 	"github.com/yuorei/attendance/src/usecase/port"
 )
 
 type UseCase struct {
 	port.AttendanceLogInputPort
+	port.OAuthStateInputPort
+	port.SlackTokenInputPort
+	port.IdempotencyInputPort
 }
 
 type Repository struct {
 	attendanceLogRepository *AttendanceLogUseCase
+	oauthStateRepository    *OAuthStateUseCase
+	slackTokenRepository    *SlackTokenUseCase
+	idempotencyRepository   *IdempotencyUseCase
+	clock                   port.Clock
 }
 
 func NewUseCase(repository *Repository) *UseCase {
 	return &UseCase{
 		AttendanceLogInputPort: repository,
+		OAuthStateInputPort:    repository,
+		SlackTokenInputPort:    repository,
+		IdempotencyInputPort:   repository,
 	}
 }
 
-func NewRepository(infra *infrastructure.Infrastructure) *Repository {
-	attendanceLog := NewAttendanceLogRepository(infra)
+func NewRepository(attendanceLogRepository port.AttendanceLogRepository, oauthStateRepository port.OAuthStateRepository, slackTokenRepository port.SlackTokenRepository, idempotencyRepository port.IdempotencyRepository, clock port.Clock) *Repository {
+	attendanceLog := NewAttendanceLogRepository(attendanceLogRepository)
+	oauthState := NewOAuthStateRepository(oauthStateRepository)
+	slackToken := NewSlackTokenRepository(slackTokenRepository)
+	idempotency := NewIdempotencyRepository(idempotencyRepository)
 	return &Repository{
 		attendanceLogRepository: attendanceLog,
+		oauthStateRepository:    oauthState,
+		slackTokenRepository:    slackToken,
+		idempotencyRepository:   idempotency,
+		clock:                   clock,
 	}
 }
@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yuorei/attendance/src/adapter/infrastructure/memory"
+	"github.com/yuorei/attendance/src/driver/clock"
+)
+
+// newTestRepository は DynamoDB の代わりにインメモリのリポジトリを使い、時刻を now に固定した Repository を返す。
+func newTestRepository(t *testing.T, now time.Time) (*Repository, *clock.FixedClock) {
+	t.Helper()
+	repo := memory.NewMemory()
+	fixedClock := clock.NewFixedClock(now)
+	return NewRepository(repo, repo, repo, repo, fixedClock), fixedClock
+}
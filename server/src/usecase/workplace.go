@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/yuorei/attendance/src/domain"
+)
+
+// CreateTeamWorkplace はチームの職場を作る。作ったユーザーが職場の管理者になり、職場名と設定を変更できる。
+// メンバーは JoinWorkplace でそれぞれのチャンネルから参加する。timezone が空の場合は domain.DefaultTimezone になる。
+func (r *Repository) CreateTeamWorkplace(ctx context.Context, teamId, userId, workplace, timezone string) (*domain.Workplace, error) {
+	workplace, err := domain.ValidateWorkplaceName(workplace)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkTeamWorkplaceName(ctx, teamId, "", workplace); err != nil {
+		return nil, err
+	}
+
+	var settings domain.WorkplaceSettings
+	if err := settings.Set("timezone", timezone); err != nil {
+		return nil, err
+	}
+
+	u, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	newWorkplace := domain.NewWorkplace(u.String(), teamId, userId, workplace, settings, r.clock.Now())
+	newWorkplace.Shared = true
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBCreateWorkplace(ctx, newWorkplace)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetTeamWorkplaces はチームの職場を作成順にすべて返す。
+func (r *Repository) GetTeamWorkplaces(ctx context.Context, teamId string) ([]domain.Workplace, error) {
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBGetTeamWorkplaces(ctx, teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// JoinWorkplace はチームの職場 workplaceId に参加し、呼び出し元のチャンネルに登録する。
+// 職場には1つのチャンネルからだけ参加できる。
+func (r *Repository) JoinWorkplace(ctx context.Context, teamId, channelId, userId, workplaceId string) (*domain.WorkplaceBindings, error) {
+	workplace, err := r.teamWorkplace(ctx, teamId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceMembers(ctx, workplace.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if m.UserId == userId {
+			return nil, domain.NewError(domain.ErrAlreadySubscribed, "already joined workplace %q", workplace.Name)
+		}
+	}
+
+	u, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBJoinWorkplace(ctx, u.String(), teamId, channelId, userId, *workplace, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetWorkplaceMembers は職場 workplaceId と、職場に登録中のユーザーを役割とともに登録順に返す。
+// 名簿は職場に登録しているユーザーと、チームの職場のマネージャー以上が参照できる。
+// マネージャー以上には、誰が勤務中かわかるよう最後に記録した action も返す。
+func (r *Repository) GetWorkplaceMembers(ctx context.Context, teamId, userId, workplaceId string) (*domain.Workplace, []domain.WorkplaceMember, error) {
+	workplace, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplace(ctx, workplaceId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if workplace.TeamID != teamId {
+		return nil, nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+
+	bindings, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplaceMembers(ctx, workplace.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manager := workplace.RoleOf(userId).Includes(domain.RoleManager)
+	joined := false
+	members := make([]domain.WorkplaceMember, 0, len(bindings))
+	for _, b := range bindings {
+		if b.UserId == userId {
+			joined = true
+		}
+		member := domain.WorkplaceMember{
+			UserID:    b.UserId,
+			ChannelID: b.CannelId,
+			Role:      workplace.RoleOf(b.UserId),
+			JoinedAt:  b.CreatedAt,
+		}
+		if manager {
+			member.LastAction = b.LastAction
+		}
+		members = append(members, member)
+	}
+	// 個人の職場では誰でも admin になるため、登録していないユーザーには見せない
+	if !joined && !(workplace.Shared && manager) {
+		return nil, nil, domain.NewError(domain.ErrForbidden, "only members of workplace %q can see its members", workplace.Name)
+	}
+
+	return workplace, members, nil
+}
+
+// SetWorkplaceRole はチームの職場 workplaceId でのユーザー memberId の役割を role (member / manager / admin) にする。
+// admin だけが変更でき、自分の役割は変更できない（最後の admin がいなくなるのを防ぐため）。
+func (r *Repository) SetWorkplaceRole(ctx context.Context, teamId, userId, workplaceId, memberId, role string) (*domain.Workplace, error) {
+	workplace, err := r.teamWorkplace(ctx, teamId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := workplace.Authorize(userId, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	newRole, err := domain.ParseWorkplaceRole(role)
+	if err != nil {
+		return nil, err
+	}
+	if memberId == "" {
+		return nil, domain.NewError(domain.ErrValidation, "user_id is required")
+	}
+	if memberId == userId {
+		return nil, domain.NewError(domain.ErrValidation, "cannot change your own role")
+	}
+
+	roles := make(map[string]domain.WorkplaceRole, len(workplace.Roles)+1)
+	for id, existing := range workplace.Roles {
+		roles[id] = existing
+	}
+	roles[memberId] = newRole
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBUpdateWorkplaceRoles(ctx, workplace.ID, roles, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateTeamWorkplaceSettings はチームの職場 workplaceId の設定に changes（設定名 → 値）を反映する。
+// admin だけが変更でき、職場に参加していなくてもよい。
+func (r *Repository) UpdateTeamWorkplaceSettings(ctx context.Context, teamId, userId, workplaceId string, changes map[string]string) (*domain.Workplace, error) {
+	workplace, err := r.teamWorkplace(ctx, teamId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := workplace.Authorize(userId, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return r.updateWorkplaceSettings(ctx, workplace, changes)
+}
+
+// SetTeamWorkplaceHourlyWage はチームの職場 workplaceId に effectiveFrom (YYYY-MM-DD) から適用する時給を登録する。
+// admin だけが登録でき、職場に参加していなくてもよい。
+func (r *Repository) SetTeamWorkplaceHourlyWage(ctx context.Context, teamId, userId, workplaceId string, amount int64, effectiveFrom string) (*domain.Workplace, error) {
+	workplace, err := r.teamWorkplace(ctx, teamId, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := workplace.Authorize(userId, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return r.setHourlyWage(ctx, workplace, amount, effectiveFrom)
+}
+
+// teamWorkplace は team のチームの職場 workplaceId を返す。他のチームの職場と個人の職場は存在しないものとして ErrNotFound を返す。
+func (r *Repository) teamWorkplace(ctx context.Context, teamId, workplaceId string) (*domain.Workplace, error) {
+	workplace, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplace(ctx, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if workplace.TeamID != teamId || !workplace.Shared {
+		return nil, domain.NewError(domain.ErrNotFound, "Workplace not found")
+	}
+
+	return workplace, nil
+}
+
+// manageableWorkplace は職場 workplaceId を返す。userId が職場の admin でない（職場名と設定を変更できない）場合は ErrForbidden を返す。
+func (r *Repository) manageableWorkplace(ctx context.Context, userId, workplaceId string) (*domain.Workplace, error) {
+	workplace, err := r.attendanceLogRepository.attendanceLogRepository.DBGetWorkplace(ctx, workplaceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := workplace.Authorize(userId, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return workplace, nil
+}
+
+// checkTeamWorkplaceName はチームの職場のうち、ID が id 以外の職場に name と同じ名前の職場が無いか確認する。
+func (r *Repository) checkTeamWorkplaceName(ctx context.Context, teamId, id, name string) error {
+	workplaces, err := r.attendanceLogRepository.attendanceLogRepository.DBGetTeamWorkplaces(ctx, teamId)
+	if err != nil {
+		return err
+	}
+	for _, w := range workplaces {
+		if w.ID != id && w.Name == name {
+			return domain.NewError(domain.ErrAlreadySubscribed, "workplace %q already exists in this team", name)
+		}
+	}
+
+	return nil
+}
+
+// updateWorkplaceSettings は changes を職場の設定に反映して保存する。1つでも不正な値があれば何も変更しない。
+func (r *Repository) updateWorkplaceSettings(ctx context.Context, workplace *domain.Workplace, changes map[string]string) (*domain.Workplace, error) {
+	settings := workplace.WorkplaceSettings
+	for key, value := range changes {
+		if err := settings.Set(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBUpdateWorkplaceSettings(ctx, workplace.ID, settings, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// setHourlyWage は職場に effectiveFrom から適用する時給を登録する。
+// effectiveFrom が空の場合は職場のタイムゾーンでの今日から適用する。
+func (r *Repository) setHourlyWage(ctx context.Context, workplace *domain.Workplace, amount int64, effectiveFrom string) (*domain.Workplace, error) {
+	if effectiveFrom == "" {
+		loc, err := workplace.Location()
+		if err != nil {
+			return nil, err
+		}
+		effectiveFrom = r.clock.Now().In(loc).Format("2006-01-02")
+	}
+
+	settings := workplace.WorkplaceSettings
+	if err := settings.SetHourlyWage(amount, effectiveFrom); err != nil {
+		return nil, err
+	}
+
+	result, err := r.attendanceLogRepository.attendanceLogRepository.DBUpdateWorkplaceSettings(ctx, workplace.ID, settings, r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -99,6 +99,29 @@ variable "slack_redirect_uri" {
   default     = "https://your-api-gateway-url/auth/slack/callback"
 }
 
+variable "frontend_origin" {
+  type        = string
+  description = "CORSで許可するフロントエンドのオリジン（カンマ区切りで複数指定可）"
+}
+
+variable "slack_signing_secret" {
+  type        = string
+  description = "Slack Signing Secret（スラッシュコマンドの署名検証用）"
+  sensitive   = true
+}
+
+variable "token_encryption_key" {
+  type        = string
+  description = "Slackトークンを暗号化するAES-256の鍵（base64エンコードした32バイト）"
+  sensitive   = true
+}
+
+variable "session_secret" {
+  type        = string
+  description = "REST API のセッショントークン(JWT)署名用シークレット"
+  sensitive   = true
+}
+
 variable "tags" {
   description = "共通のタグ"
   type        = map(string)
@@ -28,20 +28,29 @@ module "lambda" {
   runtime       = "provided.al2023"
   filename      = var.lambda_zip_path # 先に zip 済みバイナリを配置
   environment_variables = {
-    TABLE_NAME          = module.dynamodb.table_name
-    OTEL_ENDPOINT       = var.otel_endpoint
-    OTEL_TOKEN          = var.otel_token
-    OTEL_SERVICE_NAME   = "${var.lambda_function_name}-${var.env}"
-    ENV                 = var.env
-    SLACK_CLIENT_ID     = var.slack_client_id
-    SLACK_CLIENT_SECRET = var.slack_client_secret
-    SLACK_REDIRECT_URI  = var.slack_redirect_uri
+    TABLE_NAME           = module.dynamodb.table_name
+    OTEL_ENDPOINT        = var.otel_endpoint
+    OTEL_TOKEN           = var.otel_token
+    OTEL_SERVICE_NAME    = "${var.lambda_function_name}-${var.env}"
+    ENV                  = var.env
+    SLACK_CLIENT_ID      = var.slack_client_id
+    SLACK_CLIENT_SECRET  = var.slack_client_secret
+    SLACK_REDIRECT_URI   = var.slack_redirect_uri
+    SESSION_SECRET       = var.session_secret
+    SLACK_SIGNING_SECRET = var.slack_signing_secret
+    FRONTEND_ORIGIN      = var.frontend_origin
+    TOKEN_ENCRYPTION_KEY = var.token_encryption_key
   }
-  dynamodb_stream_arn = module.dynamodb.stream_arn
-  tags                = var.tags
-  table_name          = module.dynamodb.table_name
-  table_name2         = module.dynamodb.table_name2
-  aws_region          = var.aws_region
+  dynamodb_stream_arn         = module.dynamodb.stream_arn
+  tags                        = var.tags
+  table_name                  = module.dynamodb.table_name
+  table_name2                 = module.dynamodb.table_name2
+  workplace_table_name        = module.dynamodb.workplace_table_name
+  slack_token_table_name      = module.dynamodb.slack_token_table_name
+  idempotency_table_name      = module.dynamodb.idempotency_table_name
+  attendance_audit_table_name = module.dynamodb.attendance_audit_table_name
+  oauth_state_table_name      = module.dynamodb.oauth_state_table_name
+  aws_region                  = var.aws_region
 }
 
 module "apigateway" {
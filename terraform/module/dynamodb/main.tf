@@ -50,6 +50,11 @@ resource "aws_dynamodb_table" "workplace_bindings" {
     type = "S"
   }
 
+  attribute {
+    name = "workplace_id"
+    type = "S"
+  }
+
   # GSI定義（team_id, channel_id, user_id の複合キー）
   global_secondary_index {
     name            = "CompositeKey-index"
@@ -57,5 +62,130 @@ resource "aws_dynamodb_table" "workplace_bindings" {
     projection_type = "ALL"
   }
 
+  # 職場に登録しているメンバーの一覧を取得するためのGSI
+  global_secondary_index {
+    name            = "WorkplaceID-index"
+    hash_key        = "workplace_id"
+    projection_type = "ALL"
+  }
+
+  tags = var.tags
+}
+
+# 職場の名前と設定を保管するテーブル（WorkplaceBindings の workplace_id から参照する）
+resource "aws_dynamodb_table" "workplaces" {
+  name         = "Workplaces-${var.env}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  attribute {
+    name = "team_id"
+    type = "S"
+  }
+
+  # チームの職場の一覧を取得するためのGSI
+  global_secondary_index {
+    name            = "TeamID-index"
+    hash_key        = "team_id"
+    projection_type = "ALL"
+  }
+
+  tags = var.tags
+}
+
+# マネージャーがメンバーの勤怠記録を追加・編集・削除した履歴を保管するテーブル
+resource "aws_dynamodb_table" "attendance_audit" {
+  name         = "AttendanceAudit-${var.env}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  attribute {
+    name = "workplace_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "created_at"
+    type = "S"
+  }
+
+  # 職場ごとに期間を指定して履歴を取得するためのGSI
+  global_secondary_index {
+    name            = "WorkplaceID-index"
+    hash_key        = "workplace_id"
+    range_key       = "created_at"
+    projection_type = "ALL"
+  }
+
+  tags = var.tags
+}
+
+# Slack の Bot Token / User Token を暗号化して保管するテーブル（id = team_id#user_id）
+resource "aws_dynamodb_table" "slack_tokens" {
+  name         = "SlackTokens-${var.env}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = var.tags
+}
+
+# Slack OAuth の state を保管し、コールバックで一度だけ使えるようにするテーブル（id = state の SHA-256）
+resource "aws_dynamodb_table" "oauth_states" {
+  name         = "OAuthStates-${var.env}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = var.tags
+}
+
+# Slack の再送や Idempotency-Key 付きの REST リクエストの応答を保存し、同じリクエストには保存した応答を返す
+resource "aws_dynamodb_table" "idempotency" {
+  name         = "Idempotency-${var.env}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
   tags = var.tags
 }
@@ -8,6 +8,31 @@ output "table_name2" {
   value       = aws_dynamodb_table.workplace_bindings.name
 }
 
+output "workplace_table_name" {
+  description = "職場の名前と設定を保管するDynamoDBテーブルの名前"
+  value       = aws_dynamodb_table.workplaces.name
+}
+
+output "attendance_audit_table_name" {
+  description = "勤怠記録の変更履歴を保管するDynamoDBテーブルの名前"
+  value       = aws_dynamodb_table.attendance_audit.name
+}
+
+output "slack_token_table_name" {
+  description = "Slackトークン保管用DynamoDBテーブルの名前"
+  value       = aws_dynamodb_table.slack_tokens.name
+}
+
+output "oauth_state_table_name" {
+  description = "Slack OAuth の state を保管するDynamoDBテーブルの名前"
+  value       = aws_dynamodb_table.oauth_states.name
+}
+
+output "idempotency_table_name" {
+  description = "リクエストの冪等性を保つためのDynamoDBテーブルの名前"
+  value       = aws_dynamodb_table.idempotency.name
+}
+
 output "stream_arn" {
   description = "DynamoDBストリームのARN"
   value       = aws_dynamodb_table.this.stream_arn
@@ -20,3 +20,28 @@ variable "table_name2" {
   description = "DynamoDB table name"
   type        = string
 }
+
+variable "slack_token_table_name" {
+  description = "Slackトークン保管用DynamoDBテーブルの名前"
+  type        = string
+}
+
+variable "workplace_table_name" {
+  description = "職場の名前と設定を保管するDynamoDBテーブルの名前"
+  type        = string
+}
+
+variable "attendance_audit_table_name" {
+  description = "勤怠記録の変更履歴を保管するDynamoDBテーブルの名前"
+  type        = string
+}
+
+variable "oauth_state_table_name" {
+  description = "Slack OAuth の state を保管するDynamoDBテーブルの名前"
+  type        = string
+}
+
+variable "idempotency_table_name" {
+  description = "リクエストの冪等性を保つためのDynamoDBテーブルの名前"
+  type        = string
+}